@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/compress"
@@ -17,6 +21,9 @@ import (
 	"lcor.io/songs/src/utils/middlewares"
 )
 
+// Maximum time given to save rooms and close streams on shutdown
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	// Load environment variables from .env file
 	if err := godotenv.Load(); err != nil {
@@ -31,6 +38,45 @@ func main() {
 	spotify := services.Spotify(os.Getenv("SPOTIFY_CREDENTIALS"))
 	roomRepository := repositories.GetLocalRepository()
 
+	// Accept the aliases of artists and titles edited in the database
+	aliases, err := roomRepository.GetAliases()
+	if err != nil {
+		log.Println("Error loading aliases, using bundled ones:", err)
+	} else {
+		services.UseAliases(aliases)
+	}
+
+	// Rooms saved on shutdown, unless a shared backend already keeps them
+	saveRoom := roomRepository.SaveRoom
+
+	if url := os.Getenv("REDIS_URL"); url != "" {
+		// Share rooms, events and sessions with the other instances
+		backend, err := repositories.GetRedisBackend(url)
+		if err != nil {
+			log.Fatal(err)
+		}
+		services.Mansion.UseBackend(backend.Backend())
+		middlewares.SetSessionStorage(backend.Sessions())
+		saveRoom = func(*services.Room) error { return nil }
+	} else {
+		// Persist sessions, then restore the rooms saved on last shutdown
+		middlewares.SetSessionStorage(roomRepository.Sessions())
+		rooms, err := roomRepository.GetRooms()
+		if err != nil {
+			log.Println("Error restoring rooms:", err)
+		}
+		for _, room := range rooms {
+			if err := services.Mansion.AddRoom(room); err != nil {
+				log.Println("Error restoring room", room.Id, err)
+				continue
+			}
+			if err := roomRepository.DeleteRoom(room.Id); err != nil {
+				log.Println("Error deleting room snapshot", room.Id, err)
+			}
+			log.Println("Restored room", room.Id)
+		}
+	}
+
 	// Setup logger in dev
 	if os.Getenv("ENV") == "development" {
 		app.Use(logger.New(logger.Config{}))
@@ -55,11 +101,40 @@ func main() {
 		})
 	}
 
+	// Play lyrics rounds from the lyrics files of the tracks
+	if dir := os.Getenv("LYRICS_DIR"); dir != "" {
+		services.Mansion.UseLyrics(services.FileLyrics{Dir: dir})
+	}
+
+	// Evict idle, finished and empty rooms in the background
+	stopReaper := services.Mansion.StartReaper()
+
 	// Register routes
 	routers.RegisterRoutes(app, spotify, roomRepository)
 
-	log.Fatal(app.Listen(":42068", fiber.ListenConfig{
+	// Save active rooms and close their streams on SIGTERM/SIGINT
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGTERM, os.Interrupt)
+		<-quit
+
+		log.Println("Shutting down...")
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		stopReaper()
+		if err := services.Mansion.Shutdown(ctx, saveRoom); err != nil {
+			log.Println("Error shutting down rooms:", err)
+		}
+		if err := app.ShutdownWithContext(ctx); err != nil {
+			log.Println("Error shutting down server:", err)
+		}
+	}()
+
+	if err := app.Listen(":42068", fiber.ListenConfig{
 		EnablePrintRoutes: true,
 		EnablePrefork:     false,
-	}))
+	}); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -7,11 +7,13 @@ const (
 )
 
 type Track struct {
-  ID         string
-	Artists    []Artist
-	Name       string
-	Client     Client
-	Link       string
-	PreviewUrl string
-	Image      Image
+	ID          string
+	Artists     []Artist
+	Name        string
+	Album       string
+	ReleaseDate string // Year, month and day, or only year and month or year
+	Client      Client
+	Link        string
+	PreviewUrl  string
+	Image       Image
 }
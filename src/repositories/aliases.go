@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"fmt"
+
+	"lcor.io/songs/src/utils"
+)
+
+// seedAliases fills the aliases table with the bundled aliases on first
+// start. Afterwards the table is the source of truth and can be edited.
+func (repo *RoomRepository) seedAliases() error {
+	var count int
+	if err := repo.db.QueryRow("SELECT COUNT(*) FROM aliases;").Scan(&count); err != nil {
+		return fmt.Errorf("Error counting aliases: %v", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	for canonical, aliases := range utils.BundledAliases() {
+		for _, alias := range aliases {
+			if err := repo.AddAlias(canonical, alias); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetAliases loads the alias dictionary.
+func (repo *RoomRepository) GetAliases() (*utils.AliasDictionary, error) {
+	rows, err := repo.db.Query("SELECT canonical, alias FROM aliases;")
+	if err != nil {
+		return nil, fmt.Errorf("Error getting aliases: %v", err)
+	}
+	defer rows.Close()
+
+	dictionary := utils.NewAliasDictionary()
+	for rows.Next() {
+		var canonical, alias string
+		if err := rows.Scan(&canonical, &alias); err != nil {
+			return nil, fmt.Errorf("Error reading alias: %v", err)
+		}
+		dictionary.Add(canonical, alias)
+	}
+
+	return dictionary, rows.Err()
+}
+
+func (repo *RoomRepository) AddAlias(canonical, alias string) error {
+	if _, err := repo.db.Exec(`
+    INSERT OR REPLACE INTO aliases
+      (alias, canonical)
+    VALUES
+      (?, ?);`,
+		alias,
+		canonical,
+	); err != nil {
+		return fmt.Errorf("Error inserting alias: %v", err)
+	}
+	return nil
+}
+
+func (repo *RoomRepository) DeleteAlias(alias string) error {
+	if _, err := repo.db.Exec("DELETE FROM aliases WHERE alias = ?", alias); err != nil {
+		return fmt.Errorf("Error deleting alias: %v", err)
+	}
+	return nil
+}
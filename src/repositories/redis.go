@@ -0,0 +1,312 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v3/log"
+	"github.com/redis/go-redis/v9"
+
+	"lcor.io/songs/src/models"
+	"lcor.io/songs/src/services"
+)
+
+const (
+	redisPrefix = "songs:"
+
+	// Rooms not updated for this long are considered abandoned
+	redisRoomTTL = 24 * time.Hour
+
+	// Rooms whose owner did not renew its claim for this long can be taken
+	// over, e.g. after their owner crashed
+	redisOwnerTTL = 3 * services.RoomClaimInterval
+)
+
+// Claim a room if it has no owner, or renew the claim of its owner, returning
+// the room owner
+var claimRoomScript = redis.NewScript(`
+local owner = redis.call("GET", KEYS[1])
+if not owner then
+  redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+  return ARGV[1]
+end
+if owner == ARGV[1] then
+  redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return owner
+`)
+
+// Only delete the owner of a room if it is still the releasing instance
+var releaseRoomScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisBackend shares rooms, users, events and sessions between server
+// instances through a Redis compatible server.
+type RedisBackend struct {
+	client *redis.Client
+}
+
+func GetRedisBackend(url string) (*RedisBackend, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing Redis url: %v", err)
+	}
+
+	client := redis.NewClient(opts)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("Error connecting to Redis: %v", err)
+	}
+
+	return &RedisBackend{client}, nil
+}
+
+// Backend returns the services backend sharing everything through Redis.
+func (b *RedisBackend) Backend() services.Backend {
+	return services.Backend{Rooms: b, Users: b, Bus: b, Shared: true}
+}
+
+// Sessions returns a session storage shared by every server instance.
+func (b *RedisBackend) Sessions() *RedisSessionStorage {
+	return &RedisSessionStorage{b.client}
+}
+
+func roomKey(id string) string {
+	return redisPrefix + "room:" + id
+}
+
+func roomOwnerKey(id string) string {
+	return redisPrefix + "room:" + id + ":owner"
+}
+
+const roomsKey = redisPrefix + "rooms"
+
+func (b *RedisBackend) SaveRoom(snapshot services.RoomSnapshot) error {
+	ctx := context.Background()
+
+	state, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("Error serializing room: %v", err)
+	}
+
+	if _, err := b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, roomKey(snapshot.Id), state, redisRoomTTL)
+		pipe.SAdd(ctx, roomsKey, snapshot.Id)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("Error saving room: %v", err)
+	}
+	return nil
+}
+
+func (b *RedisBackend) GetRoom(id string) (services.RoomSnapshot, error) {
+	snapshot := services.RoomSnapshot{}
+
+	state, err := b.client.Get(context.Background(), roomKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return snapshot, services.ErrRoomNotFound
+	}
+	if err != nil {
+		return snapshot, fmt.Errorf("Error getting room: %v", err)
+	}
+
+	if err := json.Unmarshal(state, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("Error deserializing room %s: %v", id, err)
+	}
+	return snapshot, nil
+}
+
+func (b *RedisBackend) GetRooms() ([]services.RoomSnapshot, error) {
+	ctx := context.Background()
+
+	ids, err := b.client.SMembers(ctx, roomsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("Error listing rooms: %v", err)
+	}
+
+	snapshots := make([]services.RoomSnapshot, 0, len(ids))
+	for _, id := range ids {
+		snapshot, err := b.GetRoom(id)
+		if errors.Is(err, services.ErrRoomNotFound) {
+			// The room expired, forget it
+			b.client.SRem(ctx, roomsKey, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+func (b *RedisBackend) DeleteRoom(id string) error {
+	ctx := context.Background()
+
+	if _, err := b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, roomKey(id), roomOwnerKey(id))
+		pipe.SRem(ctx, roomsKey, id)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("Error deleting room: %v", err)
+	}
+	return nil
+}
+
+func (b *RedisBackend) ClaimRoom(id, instance string) (string, error) {
+	owner, err := claimRoomScript.Run(context.Background(), b.client, []string{roomOwnerKey(id)}, instance, redisOwnerTTL.Milliseconds()).Text()
+	if err != nil {
+		return "", fmt.Errorf("Error claiming room: %v", err)
+	}
+	return owner, nil
+}
+
+func (b *RedisBackend) ReleaseRoom(id, instance string) error {
+	if err := releaseRoomScript.Run(context.Background(), b.client, []string{roomOwnerKey(id)}, instance).Err(); err != nil {
+		return fmt.Errorf("Error releasing room: %v", err)
+	}
+	return nil
+}
+
+const usersKey = redisPrefix + "users"
+
+func (b *RedisBackend) SaveUser(user models.User) error {
+	value, err := json.Marshal(user)
+	if err != nil {
+		return fmt.Errorf("Error serializing user: %v", err)
+	}
+	if err := b.client.HSet(context.Background(), usersKey, user.ID, value).Err(); err != nil {
+		return fmt.Errorf("Error saving user: %v", err)
+	}
+	return nil
+}
+
+func (b *RedisBackend) GetUser(id string) (*models.User, error) {
+	value, err := b.client.HGet(context.Background(), usersKey, id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errors.New("User not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error getting user: %v", err)
+	}
+
+	user := models.User{}
+	if err := json.Unmarshal(value, &user); err != nil {
+		return nil, fmt.Errorf("Error deserializing user %s: %v", id, err)
+	}
+	return &user, nil
+}
+
+func (b *RedisBackend) CountUsers() (int, error) {
+	count, err := b.client.HLen(context.Background(), usersKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("Error counting users: %v", err)
+	}
+	return int(count), nil
+}
+
+func (b *RedisBackend) Publish(topic string, msg []byte) error {
+	if err := b.client.Publish(context.Background(), redisPrefix+topic, msg).Err(); err != nil {
+		return fmt.Errorf("Error publishing on %s: %v", topic, err)
+	}
+	return nil
+}
+
+func (b *RedisBackend) Subscribe(topic string) (<-chan []byte, func()) {
+	ctx := context.Background()
+	msgs := make(chan []byte)
+	pubsub := b.client.Subscribe(ctx, redisPrefix+topic)
+
+	// Wait for the subscription to be confirmed, so no message published
+	// afterwards is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		log.Errorf("Error subscribing to %s: %v", topic, err)
+		pubsub.Close()
+		close(msgs)
+		return msgs, func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(msgs)
+		for msg := range pubsub.Channel() {
+			select {
+			case msgs <- []byte(msg.Payload):
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return msgs, func() {
+		once.Do(func() {
+			close(done)
+			pubsub.Close()
+		})
+	}
+}
+
+// RedisSessionStorage implements fiber.Storage on top of Redis.
+type RedisSessionStorage struct {
+	client *redis.Client
+}
+
+func sessionKey(key string) string {
+	return redisPrefix + "session:" + key
+}
+
+func (s *RedisSessionStorage) Get(key string) ([]byte, error) {
+	value, err := s.client.Get(context.Background(), sessionKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error getting session: %v", err)
+	}
+	return value, nil
+}
+
+func (s *RedisSessionStorage) Set(key string, val []byte, exp time.Duration) error {
+	if key == "" || len(val) == 0 {
+		return nil
+	}
+	if err := s.client.Set(context.Background(), sessionKey(key), val, exp).Err(); err != nil {
+		return fmt.Errorf("Error setting session: %v", err)
+	}
+	return nil
+}
+
+func (s *RedisSessionStorage) Delete(key string) error {
+	if err := s.client.Del(context.Background(), sessionKey(key)).Err(); err != nil {
+		return fmt.Errorf("Error deleting session: %v", err)
+	}
+	return nil
+}
+
+func (s *RedisSessionStorage) Reset() error {
+	ctx := context.Background()
+
+	iter := s.client.Scan(ctx, 0, sessionKey("*"), 0).Iterator()
+	for iter.Next(ctx) {
+		if err := s.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return fmt.Errorf("Error resetting sessions: %v", err)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("Error resetting sessions: %v", err)
+	}
+	return nil
+}
+
+// Close is a no-op, the client is owned by the Redis backend.
+func (s *RedisSessionStorage) Close() error {
+	return nil
+}
@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"lcor.io/songs/src/models"
+	"lcor.io/songs/src/services"
+)
+
+func newTestRedisBackend(t *testing.T) *RedisBackend {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	backend, err := GetRedisBackend("redis://" + server.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return backend
+}
+
+func TestRedisRoomStore(t *testing.T) {
+	backend := newTestRedisBackend(t)
+
+	snapshot := services.RoomSnapshot{Id: "room", Elapsed: time.Second}
+	if err := backend.SaveRoom(snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := backend.GetRoom("room")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Id != snapshot.Id || saved.Elapsed != snapshot.Elapsed {
+		t.Errorf("Saved room %+v, got %+v", snapshot, saved)
+	}
+	if rooms, _ := backend.GetRooms(); len(rooms) != 1 {
+		t.Errorf("Expected 1 room, got %d", len(rooms))
+	}
+
+	if owner, _ := backend.ClaimRoom("room", "a"); owner != "a" {
+		t.Errorf("First claim should own the room, owned by %s", owner)
+	}
+	if owner, _ := backend.ClaimRoom("room", "b"); owner != "a" {
+		t.Errorf("Second claim should not steal the room, owned by %s", owner)
+	}
+	backend.ReleaseRoom("room", "b")
+	if owner, _ := backend.ClaimRoom("room", "b"); owner != "a" {
+		t.Errorf("Only the owner can release the room, owned by %s", owner)
+	}
+	backend.ReleaseRoom("room", "a")
+	if owner, _ := backend.ClaimRoom("room", "b"); owner != "b" {
+		t.Errorf("Released room should be claimable, owned by %s", owner)
+	}
+
+	if err := backend.DeleteRoom("room"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.GetRoom("room"); err != services.ErrRoomNotFound {
+		t.Errorf("Expected deleted room to be missing, got %v", err)
+	}
+}
+
+func TestRedisRoomOwnership(t *testing.T) {
+	server := miniredis.RunT(t)
+	backend, err := GetRedisBackend("redis://" + server.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend.ClaimRoom("room", "a")
+	server.FastForward(redisOwnerTTL / 2)
+	if owner, _ := backend.ClaimRoom("room", "a"); owner != "a" {
+		t.Errorf("Owner should renew its claim, owned by %s", owner)
+	}
+	server.FastForward(redisOwnerTTL / 2)
+	if owner, _ := backend.ClaimRoom("room", "b"); owner != "a" {
+		t.Errorf("Renewed claim should not expire, owned by %s", owner)
+	}
+	server.FastForward(redisOwnerTTL)
+	if owner, _ := backend.ClaimRoom("room", "b"); owner != "b" {
+		t.Errorf("Expired claim should let the room be taken over, owned by %s", owner)
+	}
+}
+
+func TestRedisUserStore(t *testing.T) {
+	backend := newTestRedisBackend(t)
+
+	backend.SaveUser(models.User{ID: "1", Name: "Alice"})
+	backend.SaveUser(models.User{ID: "2", Name: "Bob"})
+
+	user, err := backend.GetUser("1")
+	if err != nil || user.Name != "Alice" {
+		t.Errorf("Expected Alice, got %v (%v)", user, err)
+	}
+	if _, err := backend.GetUser("3"); err == nil {
+		t.Error("Expected unknown user to be missing")
+	}
+	if count, _ := backend.CountUsers(); count != 2 {
+		t.Errorf("Expected 2 users, got %d", count)
+	}
+}
+
+func TestRedisEventBus(t *testing.T) {
+	backend := newTestRedisBackend(t)
+
+	msgs, unsubscribe := backend.Subscribe("topic")
+	defer unsubscribe()
+
+	if err := backend.Publish("topic", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	backend.Publish("other", []byte("ignored"))
+
+	select {
+	case msg := <-msgs:
+		if string(msg) != "hello" {
+			t.Errorf("Expected hello, got %s", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Message never received")
+	}
+
+	unsubscribe()
+	for range msgs {
+	}
+}
+
+func TestRedisSessionStorage(t *testing.T) {
+	sessions := newTestRedisBackend(t).Sessions()
+
+	sessions.Set("a", []byte("1"), 0)
+	sessions.Set("b", []byte("2"), 0)
+
+	if value, _ := sessions.Get("a"); string(value) != "1" {
+		t.Errorf("Expected 1, got %s", value)
+	}
+	sessions.Delete("a")
+	if value, _ := sessions.Get("a"); value != nil {
+		t.Errorf("Expected deleted session to be missing, got %s", value)
+	}
+	sessions.Reset()
+	if value, _ := sessions.Get("b"); value != nil {
+		t.Errorf("Expected reset session to be missing, got %s", value)
+	}
+}
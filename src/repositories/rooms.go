@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -146,12 +147,84 @@ func (repo *RoomRepository) CreateRoom(opts services.RoomOpts, playlist models.P
 	return "", nil
 }
 
-func (repo *RoomRepository) GetRoom(id int) (services.Room, error) {
-	panic("not implemented")
+// SaveRoom persists a snapshot of an active room, so it can be restored after
+// a restart.
+func (repo *RoomRepository) SaveRoom(room *services.Room) error {
+	state, err := json.Marshal(room.Snapshot())
+	if err != nil {
+		return fmt.Errorf("Error serializing room: %v", err)
+	}
+
+	if _, err := repo.db.Exec(`
+    INSERT OR REPLACE INTO room_snapshots
+      (room_id, state)
+    VALUES
+      (?, ?);`,
+		room.Id,
+		state,
+	); err != nil {
+		return fmt.Errorf("Error inserting room snapshot: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *RoomRepository) GetRoom(id string) (*services.Room, error) {
+	var state []byte
+	if err := repo.db.QueryRow("SELECT state FROM room_snapshots WHERE room_id = ?", id).Scan(&state); err != nil {
+		return nil, fmt.Errorf("Error getting room snapshot: %v", err)
+	}
+
+	snapshot := services.RoomSnapshot{}
+	if err := json.Unmarshal(state, &snapshot); err != nil {
+		return nil, fmt.Errorf("Error deserializing room %s: %v", id, err)
+	}
+
+	return services.RestoreRoom(snapshot)
+}
+
+func (repo *RoomRepository) GetRooms() ([]*services.Room, error) {
+	rows, err := repo.db.Query("SELECT room_id, state FROM room_snapshots;")
+	if err != nil {
+		return nil, fmt.Errorf("Error getting room snapshots: %v", err)
+	}
+	defer rows.Close()
+
+	rooms := make([]*services.Room, 0)
+	for rows.Next() {
+		var id string
+		var state []byte
+		if err := rows.Scan(&id, &state); err != nil {
+			return nil, fmt.Errorf("Error reading room snapshot: %v", err)
+		}
+
+		snapshot := services.RoomSnapshot{}
+		if err := json.Unmarshal(state, &snapshot); err != nil {
+			return nil, fmt.Errorf("Error deserializing room %s: %v", id, err)
+		}
+		// A room that can no longer be restored must not keep the others from
+		// being restored
+		room, err := services.RestoreRoom(snapshot)
+		if err != nil {
+			log.Errorf("%v", err)
+			continue
+		}
+		rooms = append(rooms, room)
+	}
+
+	return rooms, rows.Err()
+}
+
+func (repo *RoomRepository) DeleteRoom(id string) error {
+	if _, err := repo.db.Exec("DELETE FROM room_snapshots WHERE room_id = ?", id); err != nil {
+		return fmt.Errorf("Error deleting room snapshot: %v", err)
+	}
+	return nil
 }
 
-func (repo *RoomRepository) GetRooms() ([]services.Room, error) {
-	panic("not implemented")
+// Sessions returns a session storage backed by the repository database.
+func (repo *RoomRepository) Sessions() *SessionStorage {
+	return &SessionStorage{repo.db}
 }
 
 func GetLocalRepository() *RoomRepository {
@@ -169,7 +242,12 @@ func GetLocalRepository() *RoomRepository {
 		panic(fmt.Errorf("Error executing init.sql: %v", err))
 	}
 
-	return &RoomRepository{db}
+	repo := &RoomRepository{db}
+	if err := repo.seedAliases(); err != nil {
+		panic(err)
+	}
+
+	return repo
 }
 
 func InitDb() {
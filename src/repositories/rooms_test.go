@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lcor.io/songs/src/models"
+	"lcor.io/songs/src/services"
+)
+
+func newTestRoomRepository(t *testing.T) *RoomRepository {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "rooms.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	init, err := os.ReadFile("../utils/init.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(string(init)); err != nil {
+		t.Fatal(err)
+	}
+	return &RoomRepository{db}
+}
+
+func TestRoomRepositorySnapshots(t *testing.T) {
+	repo := newTestRoomRepository(t)
+
+	// A room saved in the middle of its second round
+	playlist := models.Playlist{ID: "playlist", Name: "Playlist", Tracks: []models.Track{
+		{ID: "bohemian", Name: "Bohemian Rhapsody", Artists: []models.Artist{{ID: "queen", Name: "Queen"}}},
+		{ID: "jean", Name: "Billie Jean", Artists: []models.Artist{{ID: "jackson", Name: "Michael Jackson"}}},
+	}}
+	room, err := services.NewRoom(playlist, services.WithSeed(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := room.Snapshot()
+	snapshot.PlayedTracks = playlist.Tracks
+	snapshot.Elapsed = 10 * time.Second
+	snapshot.Players = []services.PlayerSnapshot{{Id: "user", PlayerId: "user", Name: "User", Score: 120}}
+	room, err = services.RestoreRoom(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.SaveRoom(room); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := repo.GetRoom(room.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := saved.Snapshot()
+	if restored.Id != room.Id || restored.Opts.Seed != 1 || len(restored.PlayedTracks) != 2 {
+		t.Errorf("Saved room %+v, got %+v", snapshot, restored)
+	}
+	if restored.Elapsed != 10*time.Second {
+		t.Errorf("Expected the round to resume after 10s, got %v", restored.Elapsed)
+	}
+	if len(restored.Players) != 1 || restored.Players[0].Score != 120 {
+		t.Errorf("Expected the player and their score, got %+v", restored.Players)
+	}
+
+	// Saving the room again replaces its snapshot
+	if err := repo.SaveRoom(room); err != nil {
+		t.Fatal(err)
+	}
+	if rooms, err := repo.GetRooms(); err != nil || len(rooms) != 1 || rooms[0].Id != room.Id {
+		t.Errorf("Expected room %s, got %v, %v", room.Id, rooms, err)
+	}
+
+	if err := repo.DeleteRoom(room.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetRoom(room.Id); err == nil {
+		t.Error("Expected deleted room to be missing")
+	}
+	if rooms, _ := repo.GetRooms(); len(rooms) != 0 {
+		t.Errorf("Expected no room, got %d", len(rooms))
+	}
+}
+
+func TestSessionStorage(t *testing.T) {
+	sessions := newTestRoomRepository(t).Sessions()
+
+	sessions.Set("a", []byte("1"), 0)
+	sessions.Set("b", []byte("2"), time.Hour)
+	sessions.Set("c", []byte("3"), time.Nanosecond)
+
+	if value, _ := sessions.Get("a"); string(value) != "1" {
+		t.Errorf("Expected 1, got %s", value)
+	}
+	if value, _ := sessions.Get("b"); string(value) != "2" {
+		t.Errorf("Expected 2, got %s", value)
+	}
+	if value, _ := sessions.Get("c"); value != nil {
+		t.Errorf("Expected expired session to be missing, got %s", value)
+	}
+	sessions.Set("a", []byte("4"), 0)
+	if value, _ := sessions.Get("a"); string(value) != "4" {
+		t.Errorf("Expected replaced session 4, got %s", value)
+	}
+	sessions.Delete("a")
+	if value, _ := sessions.Get("a"); value != nil {
+		t.Errorf("Expected deleted session to be missing, got %s", value)
+	}
+	sessions.Reset()
+	if value, _ := sessions.Get("b"); value != nil {
+		t.Errorf("Expected reset session to be missing, got %s", value)
+	}
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SessionStorage implements fiber.Storage on top of SQLite, so sessions, and
+// the users they point to, survive restarts.
+type SessionStorage struct {
+	db *sql.DB
+}
+
+func (s *SessionStorage) Get(key string) ([]byte, error) {
+	var value []byte
+	var expiresOn int64
+	err := s.db.QueryRow("SELECT value, expires_on FROM sessions WHERE key = ?", key).Scan(&value, &expiresOn)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error getting session: %v", err)
+	}
+
+	if expiresOn != 0 && expiresOn <= time.Now().Unix() {
+		return nil, nil
+	}
+	return value, nil
+}
+
+func (s *SessionStorage) Set(key string, val []byte, exp time.Duration) error {
+	if key == "" || len(val) == 0 {
+		return nil
+	}
+
+	var expiresOn int64
+	if exp > 0 {
+		expiresOn = time.Now().Add(exp).Unix()
+	}
+
+	if _, err := s.db.Exec(`
+    INSERT OR REPLACE INTO sessions
+      (key, value, expires_on)
+    VALUES
+      (?, ?, ?);`,
+		key,
+		val,
+		expiresOn,
+	); err != nil {
+		return fmt.Errorf("Error setting session: %v", err)
+	}
+	return nil
+}
+
+func (s *SessionStorage) Delete(key string) error {
+	if _, err := s.db.Exec("DELETE FROM sessions WHERE key = ?", key); err != nil {
+		return fmt.Errorf("Error deleting session: %v", err)
+	}
+	return nil
+}
+
+func (s *SessionStorage) Reset() error {
+	if _, err := s.db.Exec("DELETE FROM sessions;"); err != nil {
+		return fmt.Errorf("Error resetting sessions: %v", err)
+	}
+	return nil
+}
+
+// Close is a no-op, the database is owned by the room repository.
+func (s *SessionStorage) Close() error {
+	return nil
+}
@@ -1,7 +1,8 @@
 package routers
 
 import (
-	"time"
+	"errors"
+	"strings"
 
 	"github.com/gofiber/fiber/v3"
 
@@ -13,9 +14,27 @@ import (
 	"lcor.io/songs/src/utils"
 )
 
+type RoomSettings struct {
+	Mode              services.GameMode        `form:"mode"`
+	Matcher           string                   `form:"matcher"`
+	Scoring           string                   `form:"scoring"`
+	ScoreDecay        services.ScoreDecay      `form:"decay"`
+	WrongGuessPenalty float32                  `form:"penalty"`
+	GuessRate         float32                  `form:"rate"`
+	MaxAttempts       int8                     `form:"attempts"`
+	Hints             int                      `form:"hints"`
+	SkipMajority      float32                  `form:"skip"`
+	FirstFinders      int                      `form:"finders"`
+	Teams             string                   `form:"teams"` // Separated by commas
+	RoundTypes        []services.RoundType     `form:"rounds"`
+	Targets           []services.Target        `form:"targets"`
+	Elimination       services.EliminationRule `form:"elimination"`
+	ChronologyRound   bool                     `form:"chronology"`
+}
+
 func RegisterCreateRoutes(router fiber.Router, spotify *services.SpotifyService, repo *repositories.RoomRepository) {
 	router.Get("/", func(c fiber.Ctx) error {
-		return utils.TemplRender(&c, pages.Create())
+		return utils.TemplRender(&c, pages.Create(services.Mansion.HasLyrics()))
 	})
 
 	router.Get("/featured", func(ctx fiber.Ctx) error {
@@ -32,8 +51,39 @@ func RegisterCreateRoutes(router fiber.Router, spotify *services.SpotifyService,
 	router.Post("/:id", func(c fiber.Ctx) error {
 		id := c.Params("id")
 
+		form := new(RoomSettings)
+		if err := c.Bind().Form(form); err != nil {
+			return err
+		}
+		settings := services.RoomSettings{
+			Mode:              form.Mode,
+			Matcher:           form.Matcher,
+			Scoring:           form.Scoring,
+			ScoreDecay:        form.ScoreDecay,
+			WrongGuessPenalty: form.WrongGuessPenalty,
+			GuessRate:         form.GuessRate,
+			MaxAttempts:       form.MaxAttempts,
+			Hints:             form.Hints,
+			SkipMajority:      form.SkipMajority,
+			FirstFinders:      form.FirstFinders,
+			RoundTypes:        form.RoundTypes,
+			Targets:           form.Targets,
+			Elimination:       form.Elimination,
+			ChronologyRound:   form.ChronologyRound,
+		}
+		for _, team := range strings.Split(form.Teams, ",") {
+			if team = strings.TrimSpace(team); team != "" {
+				settings.Teams = append(settings.Teams, team)
+			}
+		}
+
 		playlist := spotify.GetPlaylist(id)
-		room := services.Mansion.NewRoom(playlist)
+		room, err := services.Mansion.NewRoom(playlist, settings.Options()...)
+		if errors.Is(err, services.ErrUnknownScoring) {
+			return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+		} else if err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).SendString(err.Error())
+		}
 
 		c.Set("HX-Location", "/play/"+room.Id)
 		return c.SendStatus(fiber.StatusCreated)
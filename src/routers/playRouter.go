@@ -3,9 +3,11 @@ package routers
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/a-h/templ"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/log"
 	"github.com/valyala/fasthttp"
@@ -23,6 +25,22 @@ type Guess struct {
 	Guess string `form:"guess"`
 }
 
+type Choice struct {
+	Choice string `form:"choice"`
+}
+
+type Team struct {
+	Team string `form:"team"`
+}
+
+type Message struct {
+	Message string `form:"message"`
+}
+
+type Order struct {
+	Order []string `form:"order"`
+}
+
 func RegisterPlayRoutes(router fiber.Router) {
 	router.Get("/", func(ctx fiber.Ctx) error {
 		return utils.TemplRender(&ctx, playIndex.Play())
@@ -53,7 +71,7 @@ func RegisterPlayRoutes(router fiber.Router) {
 
 		log.Infof("%d players in the room", len(room.Players))
 
-		return utils.TemplRender(&ctx, playPage.Playlist(room))
+		return utils.TemplRender(&ctx, playPage.Playlist(room, session))
 	})
 
 	router.Get("/:id/scores", func(ctx fiber.Ctx) error {
@@ -62,24 +80,27 @@ func RegisterPlayRoutes(router fiber.Router) {
 			return err
 		}
 
+		events, unsubscribe := room.Events()
+
 		baseContext := ctx.Status(fiber.StatusOK).Context()
 		baseContext.SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+			defer unsubscribe()
 			for {
 				select {
-				case scores := <-room.Scores:
-
-					htmlWriter := &strings.Builder{}
-					base.Scores(scores).Render(context.Background(), htmlWriter)
-					msg := htmlWriter.String()
-					if _, err := fmt.Fprintf(w, "data: %s\n\n", msg); err != nil {
+				case event, ok := <-events:
+					if !ok || event.Kind == services.ClosedEvent {
 						return
 					}
-
-					err := w.Flush()
-					if err != nil {
+					if event.Kind != services.ScoresEvent {
+						continue
+					}
+					if err := sendEvent(w, base.Scores(event.Scores, event.TeamScores)); err != nil {
 						return
 					}
 
+				case <-services.Mansion.Closing():
+					return
+
 				case <-baseContext.Done():
 					return
 				}
@@ -102,8 +123,112 @@ func RegisterPlayRoutes(router fiber.Router) {
 			return err
 		}
 
-		guessResult := room.GuessResult(session, guess.Guess)
-		return utils.TemplRender(&ctx, playPage.GuessResult(room.PlayedTracks[len(room.PlayedTracks)-1], *guessResult))
+		guessResult, err := room.GuessResult(session, guess.Guess)
+		return renderGuessResult(ctx, room, guessResult, err)
+	})
+
+	router.Post("/:id/choose", func(ctx fiber.Ctx) error {
+		session := fiber.Locals[string](ctx, "session")
+
+		room, err := services.Mansion.GetRoom(ctx.Params("id", ""))
+		if err != nil {
+			return err
+		}
+
+		choice := new(Choice)
+		if err := ctx.Bind().Form(choice); err != nil {
+			return err
+		}
+
+		guessResult, err := room.Choose(session, choice.Choice)
+		return renderGuessResult(ctx, room, guessResult, err)
+	})
+
+	router.Post("/:id/team", func(ctx fiber.Ctx) error {
+		session := fiber.Locals[string](ctx, "session")
+
+		room, err := services.Mansion.GetRoom(ctx.Params("id", ""))
+		if err != nil {
+			return err
+		}
+
+		team := new(Team)
+		if err := ctx.Bind().Form(team); err != nil {
+			return err
+		}
+
+		if err := room.JoinTeam(session, team.Team); err != nil {
+			return utils.TemplRender(&ctx, base.Notice(err.Error()))
+		}
+		return utils.TemplRender(&ctx, base.Teams(room.Id, room.Teams(), team.Team))
+	})
+
+	router.Post("/:id/chat", func(ctx fiber.Ctx) error {
+		session := fiber.Locals[string](ctx, "session")
+
+		room, err := services.Mansion.GetRoom(ctx.Params("id", ""))
+		if err != nil {
+			return err
+		}
+
+		message := new(Message)
+		if err := ctx.Bind().Form(message); err != nil {
+			return err
+		}
+
+		// The message reaches the sender through their own stream
+		if err := room.SendTeamMessage(session, message.Message); err != nil {
+			return utils.TemplRender(&ctx, base.Notice(err.Error()))
+		}
+		return ctx.SendStatus(fiber.StatusNoContent)
+	})
+
+	router.Post("/:id/buzz", func(ctx fiber.Ctx) error {
+		session := fiber.Locals[string](ctx, "session")
+
+		room, err := services.Mansion.GetRoom(ctx.Params("id", ""))
+		if err != nil {
+			return err
+		}
+
+		if err := room.Buzz(session); err != nil {
+			return utils.TemplRender(&ctx, base.Notice(err.Error()))
+		}
+		return ctx.SendStatus(fiber.StatusNoContent)
+	})
+
+	router.Post("/:id/skip", func(ctx fiber.Ctx) error {
+		session := fiber.Locals[string](ctx, "session")
+
+		room, err := services.Mansion.GetRoom(ctx.Params("id", ""))
+		if err != nil {
+			return err
+		}
+
+		if err := room.VoteSkip(session); err != nil {
+			return utils.TemplRender(&ctx, base.Notice(err.Error()))
+		}
+		return ctx.SendStatus(fiber.StatusNoContent)
+	})
+
+	router.Post("/:id/order", func(ctx fiber.Ctx) error {
+		session := fiber.Locals[string](ctx, "session")
+
+		room, err := services.Mansion.GetRoom(ctx.Params("id", ""))
+		if err != nil {
+			return err
+		}
+
+		order := new(Order)
+		if err := ctx.Bind().Form(order); err != nil {
+			return err
+		}
+
+		points, err := room.OrderTracks(session, order.Order)
+		if err != nil {
+			return utils.TemplRender(&ctx, base.Notice(err.Error()))
+		}
+		return utils.TemplRender(&ctx, base.Notice(fmt.Sprintf("You earned %d points ordering the tracks", int(points))))
 	})
 
 	router.Get("/:id/events", func(c fiber.Ctx) error {
@@ -115,36 +240,75 @@ func RegisterPlayRoutes(router fiber.Router) {
 		}
 
 		// Set the players nonce
-		player := room.Players[session]
-		nonce := player.Nonce + 1
-		player.Nonce = nonce
+		nonce := room.Connect(session)
+		events, unsubscribe := room.Events()
 
 		// Create an http stream response
 		baseContext := c.Status(fiber.StatusOK).Context()
 		baseContext.SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+			defer unsubscribe()
+
+			// Catch up with the current track
+			round := room.Round()
+			if playedTracks := room.Played(); len(playedTracks) > 0 {
+				if err := sendEvent(w, base.Audio(playedTracks, playedTracks[len(playedTracks)-1], round.Audible())); err != nil {
+					log.Infof("Error  while flushing: %v. Closing the connection.\n", err)
+					room.RemovePlayer(session, nonce)
+					return
+				}
+			}
+			if round.Type != "" {
+				if err := sendEvent(w, base.RoundPrompt(round, room.Opts().TrackDuration)); err != nil {
+					log.Infof("Error  while flushing: %v. Closing the connection.\n", err)
+					room.RemovePlayer(session, nonce)
+					return
+				}
+			}
+			if hint, revealed := room.Hint(); revealed {
+				if err := sendEvent(w, base.Hint(hint)); err != nil {
+					log.Infof("Error  while flushing: %v. Closing the connection.\n", err)
+					room.RemovePlayer(session, nonce)
+					return
+				}
+			}
+			if tracks := room.Chronology(); len(tracks) > 0 {
+				if err := sendEvent(w, base.Chronology(room.Id, tracks)); err != nil {
+					log.Infof("Error  while flushing: %v. Closing the connection.\n", err)
+					room.RemovePlayer(session, nonce)
+					return
+				}
+			}
+			if choices := room.Choices(); len(choices) > 0 {
+				if err := sendEvent(w, base.Choices(room.Id, choices)); err != nil {
+					log.Infof("Error  while flushing: %v. Closing the connection.\n", err)
+					room.RemovePlayer(session, nonce)
+					return
+				}
+			}
+
 			for {
 				select {
-				case track := <-room.CurrentTrack:
-
-					htmlWriter := &strings.Builder{}
-					base.Audio(room, track).Render(context.Background(), htmlWriter)
-					msg := htmlWriter.String()
-					if _, err := fmt.Fprintf(w, "data: %s\n\n", msg); err != nil {
-						log.Infof("Error  while flushing: %v. Closing the connection.\n", err)
-						room.RemovePlayer(session, nonce)
+				case event, ok := <-events:
+					if !ok || event.Kind == services.ClosedEvent {
+						log.Infof("Room %s closed, closing connection", room.Id)
 						return
 					}
-
-					err := w.Flush()
-					if err != nil {
-						log.Infof("Error  while flushing: %v. Closing the connection.\n", err)
-						room.RemovePlayer(session, nonce)
-						return
+					for _, component := range eventComponents(room, session, event) {
+						if err := sendEvent(w, component); err != nil {
+							log.Infof("Error  while flushing: %v. Closing the connection.\n", err)
+							room.RemovePlayer(session, nonce)
+							return
+						}
 					}
 
+				case <-services.Mansion.Closing():
+					log.Infof("Server shutting down, closing connection to room %s", room.Id)
+					sendEvent(w, base.Notice("Server restarting, the game will resume shortly"))
+					return
+
 				case <-baseContext.Done():
 					log.Info("Client disconnected, closing connection")
-					room.RemovePlayer(session, room.Players[session].Nonce)
+					room.RemovePlayer(session, nonce)
 					return
 				}
 			}
@@ -154,6 +318,90 @@ func RegisterPlayRoutes(router fiber.Router) {
 	}, setSSEHeaders)
 }
 
+// Components sent to a player for a room event, if it concerns them
+func eventComponents(room *services.Room, session string, event services.RoomEvent) []templ.Component {
+	switch event.Kind {
+	case services.TrackEvent:
+		preview := event.Round == nil || event.Round.Audible()
+		components := []templ.Component{base.Audio(event.PlayedTracks, event.Track, preview)}
+		if event.Round != nil {
+			components = append(components, base.RoundPrompt(*event.Round, room.Opts().TrackDuration))
+		}
+		if len(event.Choices) > 0 {
+			components = append(components, base.Choices(room.Id, event.Choices))
+		}
+		return components
+	case services.ChatEvent:
+		// Team chat messages only reach the teammates of the sender
+		if event.Message != nil && event.Team == room.TeamOf(session) {
+			return []templ.Component{base.ChatMessage(*event.Message)}
+		}
+	case services.HintEvent:
+		if event.Hint != nil {
+			return []templ.Component{base.Hint(*event.Hint)}
+		}
+	case services.EliminationEvent:
+		return []templ.Component{base.Elimination(event.Eliminated, event.Winner, room.IsSpectator(session))}
+	case services.BuzzEvent:
+		return []templ.Component{base.Buzz(event.Buzzer, room.Opts().BuzzerAnswerTime)}
+	case services.ResumeEvent:
+		return []templ.Component{base.Resume(event.LockedOut)}
+	case services.SkipVoteEvent:
+		return []templ.Component{base.SkipVotes(event.Votes, event.VotesNeeded)}
+	case services.SkipEvent:
+		return []templ.Component{base.Skipped(event.Track)}
+	case services.RoundEndEvent:
+		components := []templ.Component{base.RoundEnd(event.Track, room.Opts().RevealDuration)}
+		if event.Reveal != nil {
+			components = append(components, base.Reveal(*event.Reveal))
+		}
+		return components
+	case services.RevealEvent:
+		if event.Reveal != nil {
+			return []templ.Component{base.Reveal(*event.Reveal)}
+		}
+	case services.ChronologyEvent:
+		return []templ.Component{base.Chronology(room.Id, event.Chronology)}
+	case services.ChronologyEndEvent:
+		return []templ.Component{base.ChronologyEnd(event.Chronology)}
+	}
+	return nil
+}
+
+// Render the results of a player on the current track. Guesses refused by the
+// room are shown along the current results.
+func renderGuessResult(ctx fiber.Ctx, room *services.Room, guessResult *services.GuessResult, err error) error {
+	if errors.Is(err, services.ErrNotInRoom) || errors.Is(err, services.ErrRoundNotStarted) {
+		return utils.TemplRender(&ctx, base.Notice(err.Error()))
+	}
+	if guessResult == nil {
+		log.Errorf("%v", err)
+		return fiber.ErrServiceUnavailable
+	}
+
+	rejection := ""
+	if err != nil {
+		rejection = err.Error()
+	}
+	playedTracks := room.Played()
+	if len(playedTracks) == 0 {
+		return utils.TemplRender(&ctx, base.Notice(services.ErrRoundNotStarted.Error()))
+	}
+	return utils.TemplRender(&ctx, playPage.GuessResult(playedTracks[len(playedTracks)-1], *guessResult, rejection))
+}
+
+// Render a component as a server sent event and flush it to the client
+func sendEvent(w *bufio.Writer, component templ.Component) error {
+	htmlWriter := &strings.Builder{}
+	if err := component.Render(context.Background(), htmlWriter); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", htmlWriter.String()); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
 // Middleware used to set mandatory headers for SSE
 func setSSEHeaders(c fiber.Ctx) error {
 	c.Set("Content-Type", "text/event-stream")
@@ -0,0 +1,32 @@
+package services
+
+import "lcor.io/songs/src/utils"
+
+// Alternative names of artists and titles accepted as valid guesses
+var aliases = utils.LoadAliases(utils.BundledAliases())
+
+// UseAliases replaces the alias dictionary of every room. It must be called
+// before the server starts accepting guesses.
+func UseAliases(dictionary *utils.AliasDictionary) {
+	aliases = dictionary
+}
+
+// acceptedNames returns the normalized names accepted for a title or an
+// artist: the name itself, its romanization, and their aliases.
+func acceptedNames(name string) []string {
+	names := aliases.Names(utils.Normalize(name))
+
+	if romanized := romanization(name); romanized != "" {
+		names = append(names, aliases.Names(romanized)...)
+	}
+	return names
+}
+
+// romanization returns the normalized romanization of a name written in
+// another script, or an empty string for a name already in Latin letters.
+func romanization(name string) string {
+	if romanized := utils.Normalize(utils.Romanize(name)); romanized != utils.Normalize(name) {
+		return romanized
+	}
+	return ""
+}
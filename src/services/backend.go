@@ -0,0 +1,206 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/gofiber/fiber/v3/log"
+
+	"lcor.io/songs/src/models"
+)
+
+var ErrRoomNotFound = errors.New("Room not found")
+
+// RoomStore shares the state of rooms between server instances.
+type RoomStore interface {
+	SaveRoom(snapshot RoomSnapshot) error
+	GetRoom(id string) (RoomSnapshot, error)
+	GetRooms() ([]RoomSnapshot, error)
+	DeleteRoom(id string) error
+	// ClaimRoom makes instance the owner of a room, running its game loop,
+	// unless another instance already owns it. It returns the room owner.
+	// Owners claim their rooms again every RoomClaimInterval, so a shared store
+	// can expire the ownership of an instance which stopped doing so.
+	ClaimRoom(id, instance string) (string, error)
+	// ReleaseRoom gives up the ownership of a room, if instance owns it.
+	ReleaseRoom(id, instance string) error
+}
+
+type UserStore interface {
+	SaveUser(user models.User) error
+	GetUser(id string) (*models.User, error)
+	CountUsers() (int, error)
+}
+
+// EventBus fans messages published on a topic out to every subscriber of
+// every server instance.
+type EventBus interface {
+	Publish(topic string, msg []byte) error
+	Subscribe(topic string) (msgs <-chan []byte, unsubscribe func())
+}
+
+// Backend holds where rooms, users and their events live. The in-memory
+// backend keeps everything in process, while a shared one lets several
+// instances host the same rooms.
+type Backend struct {
+	Rooms RoomStore
+	Users UserStore
+	Bus   EventBus
+	// Shared backends are kept up to date with the state of the hosted rooms,
+	// so other instances can list and take them over
+	Shared bool
+}
+
+// NewMemoryBackend returns a backend keeping everything in process.
+func NewMemoryBackend() Backend {
+	return Backend{
+		Rooms: &memoryRoomStore{rooms: map[string]RoomSnapshot{}, owners: map[string]string{}},
+		Users: &memoryUserStore{users: map[string]models.User{}},
+		Bus:   &memoryBus{subscribers: map[string][]chan []byte{}},
+	}
+}
+
+type memoryRoomStore struct {
+	mu     sync.Mutex
+	rooms  map[string]RoomSnapshot
+	owners map[string]string
+}
+
+func (s *memoryRoomStore) SaveRoom(snapshot RoomSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rooms[snapshot.Id] = snapshot
+	return nil
+}
+
+func (s *memoryRoomStore) GetRoom(id string) (RoomSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if snapshot, exists := s.rooms[id]; exists {
+		return snapshot, nil
+	}
+	return RoomSnapshot{}, ErrRoomNotFound
+}
+
+func (s *memoryRoomStore) GetRooms() ([]RoomSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshots := make([]RoomSnapshot, 0, len(s.rooms))
+	for _, snapshot := range s.rooms {
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+func (s *memoryRoomStore) DeleteRoom(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.rooms, id)
+	delete(s.owners, id)
+	return nil
+}
+
+func (s *memoryRoomStore) ClaimRoom(id, instance string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if owner, exists := s.owners[id]; exists {
+		return owner, nil
+	}
+	s.owners[id] = instance
+	return instance, nil
+}
+
+func (s *memoryRoomStore) ReleaseRoom(id, instance string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.owners[id] == instance {
+		delete(s.owners, id)
+	}
+	return nil
+}
+
+type memoryUserStore struct {
+	mu    sync.Mutex
+	users map[string]models.User
+}
+
+func (s *memoryUserStore) SaveUser(user models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[user.ID] = user
+	return nil
+}
+
+func (s *memoryUserStore) GetUser(id string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, exists := s.users[id]; exists {
+		return &user, nil
+	}
+	return nil, errors.New("User not found")
+}
+
+func (s *memoryUserStore) CountUsers() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.users), nil
+}
+
+// Number of messages buffered for each subscriber before dropping new ones
+const busBufferSize = 64
+
+// Bus of the rooms created outside of a mansion
+var localBus EventBus = &memoryBus{subscribers: map[string][]chan []byte{}}
+
+type memoryBus struct {
+	mu          sync.Mutex
+	subscribers map[string][]chan []byte
+}
+
+func (b *memoryBus) Publish(topic string, msg []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, sub := range b.subscribers[topic] {
+		select {
+		case sub <- msg:
+		default:
+			log.Warnf("Subscriber of %s is not keeping up, dropping message", topic)
+		}
+	}
+	return nil
+}
+
+func (b *memoryBus) Subscribe(topic string) (<-chan []byte, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := make(chan []byte, busBufferSize)
+	b.subscribers[topic] = append(b.subscribers[topic], sub)
+
+	var once sync.Once
+	return sub, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			b.subscribers[topic] = slices.DeleteFunc(b.subscribers[topic], func(c chan []byte) bool {
+				return c == sub
+			})
+			if len(b.subscribers[topic]) == 0 {
+				delete(b.subscribers, topic)
+			}
+			close(sub)
+		})
+	}
+}
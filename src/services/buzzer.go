@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"lcor.io/songs/src/models"
+)
+
+var (
+	ErrNoBuzzer    = errors.New("This room has no buzzer")
+	ErrBuzzerTaken = errors.New("Someone else is answering")
+	ErrLockedOut   = errors.New("You are locked out until the next track")
+	ErrBuzzFirst   = errors.New("Buzz before answering")
+)
+
+// roundBuzzer is the buzzer of the current round. It is not part of the room
+// snapshots, a restored round resuming with the clip playing.
+type roundBuzzer struct {
+	holder    string // Player allowed to answer, empty while the clip plays
+	pausedAt  time.Time
+	lockedOut map[string]bool
+	cancel    chan struct{} // Stops the answer deadline of the holder
+}
+
+// holds reports whether the player buzzed and may answer.
+func (b *roundBuzzer) holds(playerId string) bool {
+	return b != nil && b.holder != "" && b.holder == playerId
+}
+
+// roundElapsed returns the time played in the current round, leaving out the
+// pause of an ongoing buzz. It must be called with the room locked.
+func (r *Room) roundElapsed() time.Duration {
+	if r.buzzer != nil && r.buzzer.holder != "" {
+		return r.buzzer.pausedAt.Sub(r.roundStartedAt)
+	}
+	return r.opts.Clock.Now().Sub(r.roundStartedAt)
+}
+
+// Buzz gives the player the exclusive right to answer the current round,
+// pausing it for everyone until they answer or run out of time.
+func (r *Room) Buzz(playerId string) error {
+	if r.remoteOwner != "" {
+		_, err := r.forward(roomCommand{Action: buzzAction, User: models.User{ID: playerId}})
+		return err
+	}
+	if r.opts.Mode != BuzzerMode {
+		return ErrNoBuzzer
+	}
+	defer r.changed()
+
+	r.touch()
+
+	r.mu.Lock()
+	player, exists := r.Players[playerId]
+	if !exists {
+		r.mu.Unlock()
+		return ErrNotInRoom
+	}
+	if player.Spectator {
+		r.mu.Unlock()
+		return ErrSpectator
+	}
+	if r.buzzer == nil {
+		r.buzzer = &roundBuzzer{lockedOut: map[string]bool{}}
+	}
+	buzzer := r.buzzer
+	if buzzer.holder != "" {
+		r.mu.Unlock()
+		return ErrBuzzerTaken
+	}
+	if buzzer.lockedOut[playerId] {
+		r.mu.Unlock()
+		return ErrLockedOut
+	}
+	if r.roundOver {
+		r.mu.Unlock()
+		return ErrRoundOver
+	}
+
+	// The first buzz received pauses the round, so the order is decided by
+	// the room and not by the players clocks
+	now := r.opts.Clock.Now()
+	buzzer.holder = playerId
+	buzzer.pausedAt = now
+	buzzer.cancel = make(chan struct{})
+	if r.ticker != nil {
+		r.ticker.Stop()
+	}
+	deadline := r.opts.Clock.NewTicker(r.opts.BuzzerAnswerTime)
+	go r.awaitAnswer(buzzer, playerId, deadline, buzzer.cancel)
+
+	event := RoomEvent{Kind: BuzzEvent, Buzzer: player.Name, Deadline: now.Add(r.opts.BuzzerAnswerTime)}
+	r.mu.Unlock()
+
+	r.publish(event)
+	return nil
+}
+
+// awaitAnswer locks the holder of the buzzer out once they run out of time to
+// answer.
+func (r *Room) awaitAnswer(buzzer *roundBuzzer, playerId string, deadline Ticker, cancel <-chan struct{}) {
+	defer deadline.Stop()
+
+	select {
+	case <-deadline.C():
+	case <-cancel:
+		return
+	case <-r.done:
+		return
+	}
+
+	r.mu.Lock()
+	var event *RoomEvent
+	select {
+	case <-cancel:
+		// The player answered just in time
+	default:
+		event = r.releaseBuzzer(buzzer, playerId, true)
+	}
+	r.mu.Unlock()
+
+	if event != nil {
+		r.publish(*event)
+		r.changed()
+	}
+}
+
+// releaseBuzzer takes the buzzer back from the player, locking them out of
+// the round if they failed, and resumes the round where it was paused. It
+// must be called with the room locked.
+func (r *Room) releaseBuzzer(buzzer *roundBuzzer, playerId string, lockOut bool) *RoomEvent {
+	if buzzer != r.buzzer || !buzzer.holds(playerId) {
+		return nil
+	}
+	close(buzzer.cancel)
+	buzzer.holder = ""
+
+	// The pause does not count in the response times of the round
+	now := r.opts.Clock.Now()
+	r.roundStartedAt = r.roundStartedAt.Add(now.Sub(buzzer.pausedAt))
+	remaining := r.opts.TrackDuration - now.Sub(r.roundStartedAt)
+	if r.ticker != nil {
+		r.restartTimer(remaining)
+	}
+
+	event := &RoomEvent{Kind: ResumeEvent}
+	if lockOut {
+		buzzer.lockedOut[playerId] = true
+		if player, exists := r.Players[playerId]; exists {
+			event.LockedOut = player.Name
+		}
+	}
+	return event
+}
+
+// resetBuzzer clears the buzzer for a new round. It must be called with the
+// room locked.
+func (r *Room) resetBuzzer() {
+	if r.buzzer != nil && r.buzzer.holder != "" {
+		close(r.buzzer.cancel)
+	}
+	r.buzzer = nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"lcor.io/songs/src/models"
+)
+
+func TestBuzzer(t *testing.T) {
+	clock := newFakeClock()
+	room := newTestRoom(t, testPlaylist(3), WithClock(clock), WithMode(BuzzerMode), WithBuzzerAnswerTime(10*time.Second))
+	defer room.Close()
+	events, unsubscribe := room.Events()
+	defer unsubscribe()
+	room.AddPlayer(&models.User{ID: "user-0", Name: "User 0"})
+	room.AddPlayer(&models.User{ID: "user-1", Name: "User 1"})
+	track := nextTrack(t, events)
+
+	if _, err := room.GuessResult("user-0", track.Name); err != ErrBuzzFirst {
+		t.Errorf("guess before buzzing error = %v; want %v", err, ErrBuzzFirst)
+	}
+
+	// A wrong answer locks the buzzer out of the round
+	clock.Advance(5 * time.Second)
+	if err := room.Buzz("user-0"); err != nil {
+		t.Fatalf("buzz error = %v", err)
+	}
+	if event := nextEvent(t, events, BuzzEvent); event.Buzzer != "User 0" {
+		t.Errorf("buzz event %+v; want User 0 buzzing", event)
+	}
+	if err := room.Buzz("user-1"); err != ErrBuzzerTaken {
+		t.Errorf("second buzz error = %v; want %v", err, ErrBuzzerTaken)
+	}
+	clock.Advance(2 * time.Second)
+	room.GuessResult("user-0", "zzz")
+	if event := nextEvent(t, events, ResumeEvent); event.LockedOut != "User 0" {
+		t.Errorf("resume event %+v; want User 0 locked out", event)
+	}
+	if err := room.Buzz("user-0"); err != ErrLockedOut {
+		t.Errorf("buzz after lockout error = %v; want %v", err, ErrLockedOut)
+	}
+
+	// Pauses do not count in the response times
+	clock.Advance(5 * time.Second)
+	room.Buzz("user-1")
+	clock.Advance(5 * time.Second)
+	result, err := room.GuessResult("user-1", track.Name)
+	if err != nil || result.Title != Valid || result.ResponseTime() != 10*time.Second {
+		t.Errorf("buzzer answer = %+v, %v; want title found in 10s", result, err)
+	}
+	if event := nextEvent(t, events, ResumeEvent); event.LockedOut != "" {
+		t.Errorf("resume event %+v; want nobody locked out", event)
+	}
+
+	// Running out of time counts as a wrong answer
+	room.Buzz("user-1")
+	clock.Advance(10 * time.Second)
+	if event := nextEvent(t, events, ResumeEvent); event.LockedOut != "User 1" {
+		t.Errorf("resume event %+v; want User 1 locked out", event)
+	}
+
+	// The buzzer is back for everyone once the paused round is over
+	clock.Advance(20 * time.Second)
+	nextTrack(t, events)
+	if err := room.Buzz("user-0"); err != nil {
+		t.Errorf("buzz in the next round error = %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"lcor.io/songs/src/models"
+	"lcor.io/songs/src/utils"
+)
+
+// GameMode is the way players answer each round.
+type GameMode string
+
+const (
+	TextMode   GameMode = "text"   // Players type the title and artists
+	ChoiceMode GameMode = "choice" // Players pick the track among a few options
+	BuzzerMode GameMode = "buzzer" // Players buzz to get the exclusive right to answer
+	YearMode   GameMode = "year"   // Players guess the release year of the track
+)
+
+// Valid reports whether the game mode is known. Rooms without a mode play
+// free text rounds.
+func (m GameMode) Valid() bool {
+	switch m {
+	case "", TextMode, ChoiceMode, BuzzerMode, YearMode:
+		return true
+	}
+	return false
+}
+
+// Number of options of a multiple-choice round, answer included
+const choiceNumber = 4
+
+var (
+	ErrUnknownChoice  = errors.New("Unknown choice")
+	ErrAlreadyChosen  = errors.New("You already chose for this track")
+	ErrChoiceExpected = errors.New("Pick one of the choices")
+)
+
+// Choice is an option of a multiple-choice round. Its Id is random, so the
+// options never tell which one is the answer.
+type Choice struct {
+	Id      string
+	Title   string
+	Artists string
+}
+
+// roundChoices are the options of the current round, only known to the room.
+type roundChoices struct {
+	Track   string
+	Options []Choice
+	Answer  string // Id of the right option
+}
+
+func newChoice(track models.Track) Choice {
+	artists := make([]string, 0, len(track.Artists))
+	for _, artist := range track.Artists {
+		artists = append(artists, artist.Name)
+	}
+	return Choice{Id: uuid.NewString(), Title: track.Name, Artists: strings.Join(artists, ", ")}
+}
+
+// drawChoices draws the options of a round: the track and distractors with
+// other titles from the same playlist. It must be called with the room locked.
+func (r *Room) drawChoices(track models.Track) *roundChoices {
+	answer := newChoice(track)
+	options := []Choice{answer}
+
+	taken := map[string]bool{utils.Normalize(track.Name): true}
+	for _, idx := range r.rng.Perm(len(r.Playlist.Tracks)) {
+		if len(options) == choiceNumber {
+			break
+		}
+		distractor := r.Playlist.Tracks[idx]
+		if name := utils.Normalize(distractor.Name); !taken[name] {
+			taken[name] = true
+			options = append(options, newChoice(distractor))
+		}
+	}
+
+	r.rng.Shuffle(len(options), func(i, j int) {
+		options[i], options[j] = options[j], options[i]
+	})
+	return &roundChoices{Track: track.Name, Options: options, Answer: answer.Id}
+}
+
+// has reports whether the choice is an option of the track round.
+func (c *roundChoices) has(track, choiceId string) bool {
+	return c != nil && c.Track == track && slices.ContainsFunc(c.Options, func(o Choice) bool {
+		return o.Id == choiceId
+	})
+}
+
+// Choices returns the options of the current round, empty unless the room
+// plays multiple-choice rounds.
+func (r *Room) Choices() []Choice {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.choices == nil || len(r.PlayedTracks) == 0 || r.choices.Track != r.PlayedTracks[len(r.PlayedTracks)-1].Name {
+		return nil
+	}
+	return slices.Clone(r.choices.Options)
+}
+
+// Choose answers the current multiple-choice round with one of its options.
+// Each player chooses once per round, faster right choices earning more
+// points.
+func (r *Room) Choose(playerId, choiceId string) (*GuessResult, error) {
+	if r.remoteOwner != "" {
+		reply, err := r.forward(roomCommand{Action: chooseAction, User: models.User{ID: playerId}, Choice: choiceId})
+		if rejection := guessRejection(err); rejection != nil {
+			return reply.Result.restore(), rejection
+		}
+		if notInRoom(err) {
+			return nil, ErrNotInRoom
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error forwarding choice to room %s: %v", r.Id, err)
+		}
+		return reply.Result.restore(), nil
+	}
+	defer r.changed()
+
+	r.touch()
+
+	r.mu.Lock()
+	if len(r.PlayedTracks) == 0 {
+		r.mu.Unlock()
+		return nil, ErrRoundNotStarted
+	}
+	currentTrack := r.PlayedTracks[len(r.PlayedTracks)-1]
+	player, exists := r.Players[playerId]
+	if !exists {
+		r.mu.Unlock()
+		return nil, ErrNotInRoom
+	}
+	oldGuessResult := player.Guesses[currentTrack.Name]
+
+	if player.Spectator {
+		result := *oldGuessResult
+		r.mu.Unlock()
+		return &result, ErrSpectator
+	}
+	if r.roundOver {
+		result := *oldGuessResult
+		r.mu.Unlock()
+		return &result, ErrRoundOver
+	}
+	if !r.choices.has(currentTrack.Name, choiceId) {
+		result := *oldGuessResult
+		r.mu.Unlock()
+		return &result, ErrUnknownChoice
+	}
+	if oldGuessResult.choice != "" {
+		result := *oldGuessResult
+		r.mu.Unlock()
+		return &result, ErrAlreadyChosen
+	}
+
+	responseTime := r.roundElapsed()
+	turn := Turn{
+		Elapsed:  responseTime,
+		Duration: r.opts.TrackDuration,
+		Streak:   r.streak(player),
+	}
+	newGuessResult := GuessResult{
+		Title:        Invalid,
+		Artists:      maps.Clone(oldGuessResult.Artists),
+		ranks:        map[string]int{},
+		points:       map[string]float32{},
+		responseTime: responseTime,
+		choice:       choiceId,
+	}
+
+	// A right choice finds the title and the artists at once
+	if choiceId == r.choices.Answer {
+		newGuessResult.Title = Valid
+		for artist := range newGuessResult.Artists {
+			newGuessResult.Artists[artist] = Valid
+		}
+		rank, bonus := r.findField(currentTrack.Name, titleField, player)
+		newGuessResult.ranks[titleField] = rank
+		newGuessResult.bonus = bonus
+		newGuessResult.points[titleField] = r.scoring.Points(turn, 100, true)
+	} else {
+		newGuessResult.penalty = r.scoring.Penalty(turn)
+	}
+	newGuessResult.score = newGuessResult.points[titleField] + newGuessResult.bonus - newGuessResult.penalty
+
+	player.score += newGuessResult.score - oldGuessResult.score
+	player.Guesses[currentTrack.Name] = &newGuessResult
+
+	var scores, teamScores []Score
+	if newGuessResult.score != oldGuessResult.score {
+		scores, teamScores = r.scores(), r.teamScores()
+	}
+	roundEnd := r.endRoundEarly()
+	r.mu.Unlock()
+
+	if scores != nil {
+		r.publish(RoomEvent{Kind: ScoresEvent, Scores: scores, TeamScores: teamScores})
+	}
+	if roundEnd != nil {
+		r.publish(*roundEnd)
+	}
+
+	return &newGuessResult, nil
+}
+
+// Chosen reports whether the option is the one the player chose.
+func (g GuessResult) Chosen(choiceId string) bool {
+	return g.choice != "" && g.choice == choiceId
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"lcor.io/songs/src/models"
+)
+
+func TestDrawChoices(t *testing.T) {
+	testcases := []struct {
+		tracks, want int
+	}{
+		{6, choiceNumber},
+		{4, choiceNumber},
+		{2, 2},
+	}
+
+	for _, tc := range testcases {
+		room := newTestRoom(t, testPlaylist(tc.tracks), WithMode(ChoiceMode))
+		track := room.Playlist.Tracks[0]
+		choices := room.drawChoices(track)
+
+		if len(choices.Options) != tc.want {
+			t.Errorf("%d tracks: %d options; want %d", tc.tracks, len(choices.Options), tc.want)
+		}
+		titles := map[string]bool{}
+		ids := map[string]bool{}
+		for _, option := range choices.Options {
+			titles[option.Title], ids[option.Id] = true, true
+			if option.Id == choices.Answer && option.Title != track.Name {
+				t.Errorf("answer %q; want %q", option.Title, track.Name)
+			}
+		}
+		if len(titles) != len(choices.Options) || len(ids) != len(choices.Options) {
+			t.Errorf("options %v; want distinct titles and ids", choices.Options)
+		}
+		if !choices.has(track.Name, choices.Answer) {
+			t.Errorf("options %v do not include the answer", choices.Options)
+		}
+	}
+}
+
+func TestRoomChoose(t *testing.T) {
+	clock := newFakeClock()
+	room := newTestRoom(t, testPlaylist(6), WithClock(clock), WithMode(ChoiceMode))
+	events, unsubscribe := room.Events()
+	defer unsubscribe()
+	room.AddPlayer(&models.User{ID: "user-0", Name: "User 0"})
+	room.AddPlayer(&models.User{ID: "user-1", Name: "User 1"})
+
+	var event RoomEvent
+	for event = range events {
+		if event.Kind == TrackEvent {
+			break
+		}
+	}
+	if len(event.Choices) != choiceNumber || !slices.Equal(event.Choices, room.Choices()) {
+		t.Fatalf("track event choices %v; want the %d room options", event.Choices, choiceNumber)
+	}
+	right := slices.IndexFunc(event.Choices, func(c Choice) bool { return c.Title == event.Track.Name })
+	wrong := (right + 1) % len(event.Choices)
+
+	// Choices are rewarded by speed, on top of the finder bonus
+	clock.Advance(room.opts.TrackDuration / 2)
+	result, err := room.Choose("user-0", event.Choices[right].Id)
+	if err != nil || result.Title != Valid || result.score != 100 || !result.Chosen(event.Choices[right].Id) {
+		t.Errorf("right choice = %+v, %v; want valid title and 100 points", result, err)
+	}
+	if _, err := room.Choose("user-0", event.Choices[wrong].Id); err != ErrAlreadyChosen {
+		t.Errorf("second choice error = %v; want %v", err, ErrAlreadyChosen)
+	}
+	if _, err := room.Choose("user-1", "unknown"); err != ErrUnknownChoice {
+		t.Errorf("unknown choice error = %v; want %v", err, ErrUnknownChoice)
+	}
+	if result, err := room.Choose("stranger", event.Choices[right].Id); result != nil || err != ErrNotInRoom {
+		t.Errorf("choice of a non-member = %+v, %v; want %v", result, err, ErrNotInRoom)
+	}
+	if _, err := room.GuessResult("user-1", event.Track.Name); err != ErrChoiceExpected {
+		t.Errorf("text guess error = %v; want %v", err, ErrChoiceExpected)
+	}
+	result, err = room.Choose("user-1", event.Choices[wrong].Id)
+	if err != nil || result.Title != Invalid || result.score != 0 {
+		t.Errorf("wrong choice = %+v, %v; want invalid title and no points", result, err)
+	}
+
+	// Once every player chose, right or wrong, the round ends
+	room.mu.Lock()
+	ended := room.roundOver
+	room.mu.Unlock()
+	if !ended {
+		t.Error("round still running; want it over once every player chose")
+	}
+
+	// The options survive a restart
+	restored, err := RestoreRoom(room.Snapshot(), WithClock(clock))
+	if err != nil {
+		t.Fatalf("RestoreRoom() = %v", err)
+	}
+	if choices := restored.Choices(); !slices.Equal(choices, event.Choices) {
+		t.Errorf("restored choices %v; want %v", choices, event.Choices)
+	}
+	room.Close()
+}
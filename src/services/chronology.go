@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"slices"
+
+	"lcor.io/songs/src/models"
+)
+
+const (
+	chronologyTracks = 5   // Played tracks to order in the final round
+	chronologyPoints = 200 // Earned by ordering every track right
+)
+
+var (
+	ErrNoChronology   = errors.New("There are no tracks to order")
+	ErrAlreadyOrdered = errors.New("You already ordered the tracks")
+	ErrInvalidOrder   = errors.New("Order each track once")
+)
+
+// chronologyRound is the final round of a room, in which players order some
+// of the played tracks by release date.
+type chronologyRound struct {
+	tracks  []models.Track  // In the order they are shown
+	ordered map[string]bool // Players who submitted an order
+	over    bool            // The time is up, the right order being revealed
+}
+
+// WithChronologyRound ends the game with a round ordering some of the played
+// tracks by release date.
+func WithChronologyRound() roomOptFunc {
+	return func(o *RoomOpts) {
+		o.ChronologyRound = true
+	}
+}
+
+// drawChronology draws the played tracks with a known release date to order,
+// shuffled. It is only called by the game loop, which owns the random source,
+// with the room locked.
+func (r *Room) drawChronology() *chronologyRound {
+	candidates := make([]models.Track, 0, len(r.PlayedTracks))
+	for _, track := range r.PlayedTracks {
+		if releaseYear(track) > 0 && !slices.Contains(r.skipped, track.ID) {
+			candidates = append(candidates, track)
+		}
+	}
+	if len(candidates) < 2 {
+		return nil
+	}
+
+	tracks := make([]models.Track, 0, chronologyTracks)
+	for _, idx := range r.rng.Perm(len(candidates)) {
+		if len(tracks) == chronologyTracks {
+			break
+		}
+		tracks = append(tracks, candidates[idx])
+	}
+	return &chronologyRound{tracks: tracks, ordered: map[string]bool{}}
+}
+
+// playChronology plays the final round once the last track is over.
+func (r *Room) playChronology() {
+	r.mu.Lock()
+	r.chronology = r.drawChronology()
+	if r.chronology == nil {
+		r.mu.Unlock()
+		return
+	}
+	r.restartTimer(r.opts.TrackDuration)
+	tracks := trackChoices(r.chronology.tracks)
+	r.mu.Unlock()
+
+	r.publish(RoomEvent{Kind: ChronologyEvent, Chronology: tracks})
+	r.changed()
+
+	select {
+	case <-r.done:
+		return
+	case <-r.ticker.C():
+	}
+
+	// Reveal the right order once the time is up, no order being accepted
+	// anymore
+	r.mu.Lock()
+	r.chronology.over = true
+	answer := slices.Clone(r.chronology.tracks)
+	r.mu.Unlock()
+	slices.SortStableFunc(answer, compareReleaseDates)
+	r.publish(RoomEvent{Kind: ChronologyEndEvent, Chronology: trackChoices(answer)})
+}
+
+// trackChoices returns the tracks as choices identified by the track id.
+func trackChoices(tracks []models.Track) []Choice {
+	choices := make([]Choice, 0, len(tracks))
+	for _, track := range tracks {
+		choice := newChoice(track)
+		choice.Id = track.ID
+		choices = append(choices, choice)
+	}
+	return choices
+}
+
+// compareReleaseDates orders tracks by release date, dates only known to the
+// year or month being compared on what they have in common.
+func compareReleaseDates(a, b models.Track) int {
+	n := min(len(a.ReleaseDate), len(b.ReleaseDate))
+	switch {
+	case a.ReleaseDate[:n] < b.ReleaseDate[:n]:
+		return -1
+	case a.ReleaseDate[:n] > b.ReleaseDate[:n]:
+		return 1
+	}
+	return 0
+}
+
+// Chronology returns the tracks to order in the final round, or nil outside of
+// it.
+func (r *Room) Chronology() []Choice {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.chronology == nil || r.chronology.over || !r.finishedAt.IsZero() {
+		return nil
+	}
+	return trackChoices(r.chronology.tracks)
+}
+
+// OrderTracks scores the order of the tracks of the final round given by the
+// player, from the oldest to the newest, returning the points earned. Each
+// pair of tracks in the right order earns a share of the points.
+func (r *Room) OrderTracks(playerId string, ids []string) (float32, error) {
+	if r.remoteOwner != "" {
+		reply, err := r.forward(roomCommand{Action: orderAction, User: models.User{ID: playerId}, Order: ids})
+		return reply.Result.Score, err
+	}
+	defer r.changed()
+
+	r.touch()
+
+	r.mu.Lock()
+	player, exists := r.Players[playerId]
+	switch {
+	case !exists:
+		r.mu.Unlock()
+		return 0, ErrNotInRoom
+	case player.Spectator:
+		r.mu.Unlock()
+		return 0, ErrSpectator
+	case r.chronology != nil && r.chronology.over:
+		r.mu.Unlock()
+		return 0, ErrRoundOver
+	case r.chronology == nil || !r.finishedAt.IsZero():
+		r.mu.Unlock()
+		return 0, ErrNoChronology
+	case r.chronology.ordered[playerId]:
+		r.mu.Unlock()
+		return 0, ErrAlreadyOrdered
+	}
+
+	tracks := make([]models.Track, 0, len(ids))
+	for _, id := range ids {
+		idx := slices.IndexFunc(r.chronology.tracks, func(t models.Track) bool { return t.ID == id })
+		if idx < 0 || slices.Contains(ids[:len(tracks)], id) {
+			break
+		}
+		tracks = append(tracks, r.chronology.tracks[idx])
+	}
+	if len(tracks) != len(r.chronology.tracks) || len(ids) != len(tracks) {
+		r.mu.Unlock()
+		return 0, ErrInvalidOrder
+	}
+
+	// Tracks released the same day are in the right order either way
+	right, pairs := 0, 0
+	for i := range tracks {
+		for j := i + 1; j < len(tracks); j++ {
+			pairs++
+			if compareReleaseDates(tracks[i], tracks[j]) <= 0 {
+				right++
+			}
+		}
+	}
+	points := chronologyPoints * float32(right) / float32(pairs)
+
+	r.chronology.ordered[playerId] = true
+	player.score += points
+	scores, teamScores := r.scores(), r.teamScores()
+	r.mu.Unlock()
+
+	r.publish(RoomEvent{Kind: ScoresEvent, Scores: scores, TeamScores: teamScores})
+	return points, nil
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"slices"
+)
+
+// EliminationRule decides which players leave the game after each round.
+type EliminationRule string
+
+const (
+	NoElimination EliminationRule = ""
+	// The lowest scoring players who did not find the title are eliminated
+	// after each round, until one remains
+	LowestScoreElimination EliminationRule = "lowest"
+)
+
+// Valid reports whether the elimination rule is known.
+func (e EliminationRule) Valid() bool {
+	switch e {
+	case NoElimination, LowestScoreElimination:
+		return true
+	}
+	return false
+}
+
+var ErrSpectator = errors.New("You were eliminated, you can only watch")
+
+// RoundScores are the scores of the players at the end of a round.
+type RoundScores struct {
+	Track      string
+	Scores     []Score
+	Eliminated []string // Names of the players eliminated after the round
+}
+
+// endRound records the scores of the current round and eliminates players
+// according to the room rule. It returns the events to publish, revealing the
+// track and the eliminated players, and whether a single player remains. It
+// must be called with the room locked.
+func (r *Room) endRound() ([]RoomEvent, bool) {
+	if len(r.PlayedTracks) == 0 {
+		return nil, false
+	}
+	track := r.PlayedTracks[len(r.PlayedTracks)-1]
+	round := RoundScores{Track: track.Name, Scores: r.scores()}
+	skipped := slices.Contains(r.skipped, track.ID)
+
+	// Tracks ended early were revealed already
+	var events []RoomEvent
+	if !r.roundOver && !skipped {
+		reveal := r.reveal(track)
+		events = append(events, RoomEvent{Kind: RevealEvent, Reveal: &reveal})
+	}
+
+	// Nobody is eliminated on a track skipped by the players
+	over := false
+	if r.opts.Elimination == LowestScoreElimination && !skipped {
+		eliminated := r.eliminate(track.Name)
+		for _, player := range eliminated {
+			player.Spectator = true
+			round.Eliminated = append(round.Eliminated, player.Name)
+		}
+		slices.Sort(round.Eliminated)
+
+		if len(eliminated) > 0 {
+			event := RoomEvent{Kind: EliminationEvent, Eliminated: round.Eliminated}
+			if remaining := r.contestants(); len(remaining) == 1 {
+				event.Winner = remaining[0].Name
+				over = true
+			}
+			events = append(events, event)
+		}
+	}
+
+	r.rounds = append(r.rounds, round)
+	return events, over
+}
+
+// eliminate returns the lowest scoring players who did not find the title of
+// the track, or its year in year rounds. Nobody is eliminated if it would
+// leave no player in the game. It must be called with the room locked.
+func (r *Room) eliminate(track string) []*Player {
+	contestants := r.contestants()
+	if len(contestants) <= 1 {
+		return nil
+	}
+
+	lowest := float32(math.MaxFloat32)
+	var eliminated []*Player
+	for _, player := range contestants {
+		if guess, exists := player.Guesses[track]; exists && (guess.Title == Valid || r.opts.Mode == YearMode && guess.Fields[yearField] == Valid) {
+			continue
+		}
+		switch {
+		case player.score < lowest:
+			lowest = player.score
+			eliminated = []*Player{player}
+		case player.score == lowest:
+			eliminated = append(eliminated, player)
+		}
+	}
+
+	if len(eliminated) == len(contestants) {
+		return nil
+	}
+	return eliminated
+}
+
+// contestants returns the players still in the game. It must be called with
+// the room locked.
+func (r *Room) contestants() []*Player {
+	contestants := make([]*Player, 0, len(r.Players))
+	for _, player := range r.Players {
+		if !player.Spectator {
+			contestants = append(contestants, player)
+		}
+	}
+	return contestants
+}
+
+// Rounds returns the scores of the players at the end of each round played.
+func (r *Room) Rounds() []RoundScores {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.rounds)
+}
+
+// IsSpectator reports whether the player was eliminated from the game.
+func (r *Room) IsSpectator(playerId string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	player, exists := r.Players[playerId]
+	return exists && player.Spectator
+}
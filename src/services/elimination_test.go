@@ -0,0 +1,86 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"lcor.io/songs/src/models"
+)
+
+// nextEvent waits for the next event of the kind.
+func nextEvent(t *testing.T, events <-chan RoomEvent, kind RoomEventKind) RoomEvent {
+	t.Helper()
+	for event := range events {
+		if event.Kind == kind {
+			return event
+		}
+	}
+	t.Fatalf("events closed before a %s event", kind)
+	return RoomEvent{}
+}
+
+func TestElimination(t *testing.T) {
+	clock := newFakeClock()
+	room := newTestRoom(t, testPlaylist(5), WithClock(clock), WithElimination(LowestScoreElimination))
+	defer room.Close()
+	events, unsubscribe := room.Events()
+	defer unsubscribe()
+	for _, id := range []string{"user-0", "user-1", "user-2"} {
+		room.AddPlayer(&models.User{ID: id, Name: "User " + id[len(id)-1:]})
+	}
+
+	// The player who found nothing is eliminated
+	track := nextTrack(t, events)
+	room.GuessResult("user-0", track.Name)
+	room.GuessResult("user-1", track.Artists[0].Name)
+	clock.Advance(room.opts.TrackDuration)
+	if event := nextEvent(t, events, EliminationEvent); !slices.Equal(event.Eliminated, []string{"User 2"}) || event.Winner != "" {
+		t.Errorf("first elimination %+v; want User 2 eliminated", event)
+	}
+
+	track = nextTrack(t, events)
+	if _, err := room.GuessResult("user-2", track.Name); err != ErrSpectator {
+		t.Errorf("spectator guess error = %v; want %v", err, ErrSpectator)
+	}
+	if !room.IsSpectator("user-2") || room.IsSpectator("user-1") {
+		t.Errorf("spectators: user-1 %v, user-2 %v; want only user-2", room.IsSpectator("user-1"), room.IsSpectator("user-2"))
+	}
+
+	room.GuessResult("user-0", track.Name)
+	room.GuessResult("user-1", track.Name)
+	clock.Advance(room.opts.TrackDuration)
+
+	// Finding the title keeps a player in the game, whatever their score
+	track = nextTrack(t, events)
+	room.GuessResult("user-0", "zzz")
+	room.GuessResult("user-1", track.Name)
+	clock.Advance(room.opts.TrackDuration)
+	event := nextEvent(t, events, EliminationEvent)
+	if !slices.Equal(event.Eliminated, []string{"User 0"}) || event.Winner != "User 1" {
+		t.Errorf("last elimination %+v; want User 0 eliminated and User 1 winning", event)
+	}
+
+	rounds := room.Rounds()
+	if len(rounds) != 3 || len(rounds[0].Scores) != 3 || !slices.Equal(rounds[2].Eliminated, []string{"User 0"}) {
+		t.Errorf("rounds %+v; want 3 rounds of 3 scores", rounds)
+	}
+	restored, err := RestoreRoom(room.Snapshot())
+	if err != nil {
+		t.Fatalf("RestoreRoom() = %v", err)
+	}
+	if len(restored.Rounds()) != 3 || !restored.IsSpectator("user-0") {
+		t.Errorf("restored rounds %v; want 3 rounds and user-0 spectating", restored.Rounds())
+	}
+}
+
+func TestEliminateTie(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 2, WithElimination(LowestScoreElimination))
+
+	// Eliminating every player left would end the game without a winner
+	room.mu.Lock()
+	eliminated := room.eliminate(benchmarkTrack.Name)
+	room.mu.Unlock()
+	if len(eliminated) != 0 {
+		t.Errorf("eliminated %d players; want none", len(eliminated))
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v3/log"
+
+	"lcor.io/songs/src/models"
+)
+
+type RoomEventKind string
+
+const (
+	TrackEvent         RoomEventKind = "track"
+	ScoresEvent        RoomEventKind = "scores"
+	ClosedEvent        RoomEventKind = "closed"
+	ChatEvent          RoomEventKind = "chat"
+	EliminationEvent   RoomEventKind = "elimination"    // Players were eliminated at the end of a round
+	BuzzEvent          RoomEventKind = "buzz"           // A player buzzed, pausing the round
+	ResumeEvent        RoomEventKind = "resume"         // The round resumed after a buzz
+	HintEvent          RoomEventKind = "hint"           // A hint on the track was revealed
+	SkipVoteEvent      RoomEventKind = "skip_vote"      // A player voted to skip the track
+	SkipEvent          RoomEventKind = "skip"           // The players skipped the track
+	RoundEndEvent      RoomEventKind = "round_end"      // The round ended early, revealing the track
+	RevealEvent        RoomEventKind = "reveal"         // The round ended, revealing the track
+	ChronologyEvent    RoomEventKind = "chronology"     // The final round started, ordering tracks
+	ChronologyEndEvent RoomEventKind = "chronology_end" // The final round ended, revealing the order
+)
+
+type Score struct {
+	Id    string
+	Score float32
+}
+
+// RoomEvent is pushed to every stream of a room, whatever the instance
+// serving it.
+type RoomEvent struct {
+	Kind         RoomEventKind
+	Track        models.Track
+	PlayedTracks []models.Track
+	Choices      []Choice // Options of multiple-choice rounds
+	Scores       []Score
+	TeamScores   []Score      // Standings of the teams, if played in teams
+	Team         string       // Team a chat message is sent to
+	Message      *ChatMessage // Team chat message
+	Eliminated   []string     // Names of the players eliminated after the round
+	Winner       string       // Last player standing of an elimination game
+	Buzzer       string       // Player answering after buzzing
+	Deadline     time.Time    // End of the time to answer of the buzzer
+	LockedOut    string       // Player locked out of the round by a failed answer
+	Hint         *Hint
+	Votes        int // Votes to skip the track
+	VotesNeeded  int
+	Reveal       *Reveal  // Answer of the round over and how the players did
+	Round        *Round   // What players guess the new track from
+	Chronology   []Choice // Tracks to order in the final round, by track id
+}
+
+func roomTopic(id string) string {
+	return "room:" + id
+}
+
+func (r *Room) publish(event RoomEvent) {
+	msg, err := json.Marshal(event)
+	if err != nil {
+		log.Errorf("Error serializing %s event of room %s: %v", event.Kind, r.Id, err)
+		return
+	}
+	if err := r.opts.Bus.Publish(roomTopic(r.Id), msg); err != nil {
+		log.Errorf("Error publishing %s event of room %s: %v", event.Kind, r.Id, err)
+	}
+}
+
+// Events subscribes to the events of the room. The returned function must be
+// called once the subscriber is done reading.
+func (r *Room) Events() (<-chan RoomEvent, func()) {
+	msgs, unsubscribe := r.opts.Bus.Subscribe(roomTopic(r.Id))
+	events := make(chan RoomEvent)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(events)
+		for msg := range msgs {
+			event := RoomEvent{}
+			if err := json.Unmarshal(msg, &event); err != nil {
+				log.Errorf("Error deserializing event of room %s: %v", r.Id, err)
+				continue
+			}
+			select {
+			case events <- event:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			unsubscribe()
+			close(done)
+		})
+	}
+}
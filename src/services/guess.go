@@ -0,0 +1,262 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"lcor.io/songs/src/models"
+	"lcor.io/songs/src/utils"
+)
+
+const (
+	// Guesses are truncated past this many runes and words, as nobody types a
+	// title and its artists in more
+	maxGuessLength = 150
+	maxGuessWords  = 20
+
+	// A phrase of a guess is only compared to names having up to this many
+	// more or fewer words
+	phraseSlack = 2
+
+	titleField = "title"
+)
+
+// Finder is a player who found a field of a track.
+type Finder struct {
+	PlayerId string
+	Name     string
+	Team     string
+	At       time.Time
+	Elapsed  time.Duration // Time since the start of the round, pauses left out
+}
+
+func artistField(artist string) string {
+	return "artist:" + artist
+}
+
+// trackAnswers are the names accepted for the title and artists of a track,
+// computed once per round.
+type trackAnswers struct {
+	track     string
+	title     []string
+	artists   map[string][]string // Keyed by normalized artist name
+	targets   map[string][]string // Optional targets guessed by name, keyed by field
+	romanized map[string]bool     // Names romanized from another script
+	year      int                 // Release year, if guessed
+	maxWords  int                 // Words of the longest phrase worth comparing
+}
+
+func newTrackAnswers(track models.Track, targets []Target) *trackAnswers {
+	answers := &trackAnswers{
+		track:     track.Name,
+		artists:   make(map[string][]string, len(track.Artists)),
+		targets:   make(map[string][]string, len(targets)),
+		romanized: map[string]bool{},
+	}
+	accept := func(name string) []string {
+		if romanized := romanization(name); romanized != "" {
+			answers.romanized[romanized] = true
+		}
+		return acceptedNames(name)
+	}
+
+	answers.title = accept(track.Name)
+	answers.maxWords = maxPhraseWords(answers.title)
+	for i, artist := range track.Artists {
+		names := accept(artist.Name)
+		if i > 0 && slices.Contains(targets, FeaturedTarget) {
+			answers.targets[featuredField(utils.Normalize(artist.Name))] = names
+		} else {
+			answers.artists[utils.Normalize(artist.Name)] = names
+		}
+		answers.maxWords = max(answers.maxWords, maxPhraseWords(names))
+	}
+
+	// Albums named after the track are not worth guessing twice
+	if slices.Contains(targets, AlbumTarget) && track.Album != "" && utils.Normalize(track.Album) != utils.Normalize(track.Name) {
+		names := accept(track.Album)
+		answers.targets[albumField] = names
+		answers.maxWords = max(answers.maxWords, maxPhraseWords(names))
+	}
+	if slices.Contains(targets, YearTarget) {
+		answers.year = releaseYear(track)
+	}
+	return answers
+}
+
+// answersFor returns the answers of a track, cached for the current round. It
+// must be called with the room locked.
+func (r *Room) answersFor(track models.Track) *trackAnswers {
+	if r.answers != nil && r.answers.track == track.Name {
+		return r.answers
+	}
+	answers := newTrackAnswers(track, r.opts.Targets)
+	if len(r.PlayedTracks) > 0 && r.PlayedTracks[len(r.PlayedTracks)-1].Name == track.Name {
+		r.answers = answers
+	}
+	return answers
+}
+
+// guessPhrases holds the contiguous phrases of a guess, indexed by their
+// number of words minus one.
+type guessPhrases [][]string
+
+// segmentGuess splits a guess once into its phrases of up to maxWords words.
+// Guesses in another script are also split through their romanization.
+func segmentGuess(guess string, maxWords int) guessPhrases {
+	if runes := []rune(guess); len(runes) > maxGuessLength {
+		guess = string(runes[:maxGuessLength])
+	}
+
+	segments := [][]string{strings.Fields(utils.Normalize(guess))}
+	if romanized := strings.Fields(utils.Normalize(utils.Romanize(guess))); !slices.Equal(romanized, segments[0]) {
+		segments = append(segments, romanized)
+	}
+
+	phrases := make(guessPhrases, maxWords)
+	for _, words := range segments {
+		words = words[:min(len(words), maxGuessWords)]
+		for start := range words {
+			for length := 1; length <= maxWords && start+length <= len(words); length++ {
+				phrase := strings.Join(utils.TrimArticle(words[start:start+length]), " ")
+				phrases[length-1] = append(phrases[length-1], phrase)
+			}
+		}
+	}
+	return phrases
+}
+
+// maxPhraseWords returns the number of words of the longest phrase compared
+// to names.
+func maxPhraseWords(names []string) int {
+	words := 0
+	for _, name := range names {
+		words = max(words, strings.Count(name, " ")+1+phraseSlack)
+	}
+	return words
+}
+
+// bestMatch returns the best score of the phrases of a guess having about as
+// many words as one of the accepted names. Romanized names are also compared
+// through utils.RomanizedScore, as romanizations rarely match the way players
+// spell them.
+func (r *Room) bestMatch(phrases guessPhrases, names []string, romanized map[string]bool) float32 {
+	var best float32
+	for _, name := range names {
+		words := strings.Count(name, " ") + 1
+		for length := max(1, words-phraseSlack); length <= min(len(phrases), words+phraseSlack); length++ {
+			for _, phrase := range phrases[length-1] {
+				score := r.matcher.Score(phrase, name)
+				if romanized[name] {
+					score = max(score, utils.RomanizedScore(phrase, name))
+				}
+				if best = max(best, score); best >= 100 {
+					return best
+				}
+			}
+		}
+	}
+	return best
+}
+
+// findField records the player as the next finder of a field of the track,
+// returning their rank, from 1, and the bonus it earns. In teams, the bonuses
+// reward the first teams finding the field, once per team. It must be called
+// with the room locked.
+func (r *Room) findField(track, field string, player *Player) (int, float32) {
+	if r.finders[track] == nil {
+		r.finders[track] = map[string][]Finder{}
+	}
+	previous := r.finders[track][field]
+	r.finders[track][field] = append(previous, Finder{
+		PlayerId: player.PlayerId,
+		Name:     player.Name,
+		Team:     player.Team,
+		At:       r.opts.Clock.Now(),
+		Elapsed:  r.roundElapsed(),
+	})
+
+	rank := len(previous) + 1
+	tier := rank
+	if player.Team != "" {
+		teams := make([]string, 0, len(previous))
+		for _, finder := range previous {
+			if finder.Team == player.Team {
+				return rank, 0
+			}
+			if !slices.Contains(teams, finder.Team) {
+				teams = append(teams, finder.Team)
+			}
+		}
+		tier = len(teams) + 1
+	}
+	if tier <= len(r.opts.FinderBonuses) {
+		return rank, r.opts.FinderBonuses[tier-1]
+	}
+	return rank, 0
+}
+
+// TitleFinders returns the players who found the title of a track, in order.
+func (r *Room) TitleFinders(track string) []Finder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.finders[track][titleField])
+}
+
+// ArtistFinders returns the players who found an artist of a track, in order.
+func (r *Room) ArtistFinders(track, artist string) []Finder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.finders[track][artistField(utils.Normalize(artist))])
+}
+
+// TitleRank returns the rank of the player among the ones who found the
+// title, from 1, or 0 if they did not find it.
+func (g GuessResult) TitleRank() int {
+	return g.ranks[titleField]
+}
+
+// ArtistRank returns the rank of the player among the ones who found an
+// artist, from 1, or 0 if they did not find it.
+func (g GuessResult) ArtistRank(artist string) int {
+	return g.ranks[artistField(utils.Normalize(artist))]
+}
+
+// Bonus returns the points earned by finding fields before other players.
+func (g GuessResult) Bonus() float32 {
+	return g.bonus
+}
+
+// foundAny reports whether the player found a field of the track.
+func (g GuessResult) foundAny() bool {
+	if g.Title == Valid {
+		return true
+	}
+	for _, validity := range g.Artists {
+		if validity == Valid {
+			return true
+		}
+	}
+	for _, validity := range g.Fields {
+		if validity == Valid {
+			return true
+		}
+	}
+	return false
+}
+
+// ResponseTime returns the time between the start of the round and the last
+// guess of the player.
+func (g GuessResult) ResponseTime() time.Duration {
+	return g.responseTime
+}
+
+// HasArtist reports whether the artist of the track is guessed as such,
+// rather than as a featured artist.
+func (g GuessResult) HasArtist(artist string) bool {
+	_, exists := g.Artists[utils.Normalize(artist)]
+	return exists
+}
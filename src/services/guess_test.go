@@ -0,0 +1,141 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"lcor.io/songs/src/models"
+)
+
+// startRound joins players to a room playing a single track, and waits for
+// the track to start.
+func startRound(tb testing.TB, track models.Track, players int, opts ...roomOptFunc) *Room {
+	tb.Helper()
+
+	clock := newFakeClock()
+	playlist := models.Playlist{ID: "playlist", Name: "Playlist", Tracks: []models.Track{track}}
+	room := newTestRoom(tb, playlist, append([]roomOptFunc{WithClock(clock)}, opts...)...)
+
+	events, unsubscribe := room.Events()
+	defer unsubscribe()
+	for i := 0; i < players; i++ {
+		room.AddPlayer(&models.User{ID: fmt.Sprintf("user-%d", i), Name: fmt.Sprintf("User %d", i)})
+	}
+	for event := range events {
+		if event.Kind == TrackEvent {
+			break
+		}
+	}
+	return room
+}
+
+var benchmarkTrack = models.Track{
+	ID:   "track",
+	Name: "Don't Stop Me Now - Remastered 2011",
+	Artists: []models.Artist{
+		{ID: "queen", Name: "Queen"},
+		{ID: "freddie", Name: "Freddie Mercury"},
+	},
+}
+
+func BenchmarkGuessResult(b *testing.B) {
+	guesses := map[string]string{
+		"short": "dont stop",
+		"long":  strings.Repeat("tonight im gonna have myself a real good time ", 8),
+	}
+
+	for _, players := range []int{1, 15} {
+		for name, guess := range guesses {
+			b.Run(fmt.Sprintf("%s/%d-players", name, players), func(b *testing.B) {
+				room := startRound(b, benchmarkTrack, players, WithGuessRate(0, 0))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					room.GuessResult("user-0", guess)
+				}
+			})
+		}
+	}
+}
+
+func TestGuessFinderBonus(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 4)
+
+	want := []float32{150, 125, 115, 100}
+	for i, score := range want {
+		result, _ := room.GuessResult(fmt.Sprintf("user-%d", i), "dont stop me now")
+		if result.Title != Valid || result.score != score {
+			t.Errorf("player %d title = %v, score %v; want valid, %v", i, result.Title, result.score, score)
+		}
+	}
+
+	// Finding an artist is rewarded on its own
+	result, _ := room.GuessResult("user-3", "freddie mercury")
+	if result.Artists["freddie mercury"] != Valid || result.score != 250 {
+		t.Errorf("player 3 artist = %v, score %v; want valid, 250", result.Artists, result.score)
+	}
+}
+
+func TestGuessFinderOrder(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 3, WithFinderBonuses(10))
+	clock := room.opts.Clock.(*fakeClock)
+
+	// Each player finds a different field first
+	room.GuessResult("user-0", "queen")
+	clock.Advance(2 * time.Second)
+	room.GuessResult("user-1", "dont stop me now")
+	clock.Advance(2 * time.Second)
+	result, _ := room.GuessResult("user-2", "queen dont stop me now")
+
+	if result.TitleRank() != 2 || result.ArtistRank("Queen") != 2 || result.ArtistRank("Freddie Mercury") != 0 {
+		t.Errorf("ranks = %v; want 2nd to find the title and queen", result.ranks)
+	}
+	if result.Bonus() != 0 || result.score != 200 {
+		t.Errorf("bonus = %v, score %v; want 0, 200", result.Bonus(), result.score)
+	}
+
+	start := room.roundStartedAt
+	wantTitle := []Finder{
+		{PlayerId: "user-1", Name: "User 1", At: start.Add(2 * time.Second), Elapsed: 2 * time.Second},
+		{PlayerId: "user-2", Name: "User 2", At: start.Add(4 * time.Second), Elapsed: 4 * time.Second},
+	}
+	if finders := room.TitleFinders(benchmarkTrack.Name); !slices.Equal(finders, wantTitle) {
+		t.Errorf("title finders = %v; want %v", finders, wantTitle)
+	}
+	wantArtist := []Finder{
+		{PlayerId: "user-0", Name: "User 0", At: start},
+		{PlayerId: "user-2", Name: "User 2", At: start.Add(4 * time.Second), Elapsed: 4 * time.Second},
+	}
+	if finders := room.ArtistFinders(benchmarkTrack.Name, "Queen"); !slices.Equal(finders, wantArtist) {
+		t.Errorf("artist finders = %v; want %v", finders, wantArtist)
+	}
+	if finders := room.ArtistFinders(benchmarkTrack.Name, "Freddie Mercury"); len(finders) != 0 {
+		t.Errorf("freddie mercury finders = %v; want none", finders)
+	}
+}
+
+func TestGuessKeepsPartialResults(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 1)
+
+	if result, _ := room.GuessResult("user-0", "dont stop me"); result.Title != Partial {
+		t.Fatalf("title = %v; want partial", result.Title)
+	}
+	if result, _ := room.GuessResult("user-0", "queen"); result.Title != Partial || result.Artists["queen"] != Valid {
+		t.Errorf("title = %v, artists %v; want partial title and valid queen", result.Title, result.Artists)
+	}
+}
+
+func TestGuessLongInput(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 1, WithMaxGuessLength(0))
+
+	guess := "queen " + strings.Repeat("la ", 1000) + "dont stop me now"
+	result, _ := room.GuessResult("user-0", guess)
+	if result.Artists["queen"] != Valid {
+		t.Errorf("artists = %v; want queen found at the start of the guess", result.Artists)
+	}
+	if result.Title == Valid {
+		t.Error("title past the maximum guess length should be ignored")
+	}
+}
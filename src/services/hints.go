@@ -0,0 +1,129 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"lcor.io/songs/src/models"
+)
+
+const (
+	// Levels of hints: the letter count of the title, its first letters, then
+	// the initial of the artist
+	maxHintLevel = 3
+	// Share of the points lost by each hint revealed before finding a field
+	hintCost = 0.2
+)
+
+// Hint reveals part of the answer of the current round.
+type Hint struct {
+	Level  int
+	Title  string // Title with its hidden letters as underscores
+	Artist string // Initial of the first artist, from the last level
+}
+
+// newHint builds the hint of a level for the track.
+func newHint(track models.Track, level int) Hint {
+	title := track.Name
+	for _, separator := range []string{" (", " [", " - "} {
+		title, _, _ = strings.Cut(title, separator)
+	}
+
+	masked := strings.Builder{}
+	wordStart := true
+	for _, r := range title {
+		isLetter := unicode.IsLetter(r) || unicode.IsNumber(r)
+		switch {
+		case !isLetter:
+			masked.WriteRune(r)
+		case wordStart && level >= 2:
+			masked.WriteRune(r)
+		default:
+			masked.WriteRune('_')
+		}
+		wordStart = unicode.IsSpace(r)
+	}
+
+	hint := Hint{Level: level, Title: masked.String()}
+	if level >= 3 && len(track.Artists) > 0 {
+		initial, _ := utf8.DecodeRuneInString(track.Artists[0].Name)
+		hint.Artist = string(unicode.ToUpper(initial)) + "."
+	}
+	return hint
+}
+
+// hintLevel returns the number of hints revealed after elapsed time in a
+// round.
+func (r *Room) hintLevel(elapsed time.Duration) int {
+	level := 0
+	for _, delay := range r.opts.HintDelays {
+		if elapsed >= delay {
+			level++
+		}
+	}
+	return level
+}
+
+// hintFactor returns the share of the points still earned once level hints
+// were revealed.
+func hintFactor(level int) float32 {
+	return 1 - hintCost*float32(level)
+}
+
+// Hint returns the last hint revealed in the current round, if any.
+func (r *Room) Hint() (Hint, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.PlayedTracks) == 0 {
+		return Hint{}, false
+	}
+	level := r.hintLevel(r.roundElapsed())
+	if level == 0 {
+		return Hint{}, false
+	}
+	return newHint(r.PlayedTracks[len(r.PlayedTracks)-1], level), true
+}
+
+// revealHints pushes the hints of the round of the track as they get revealed,
+// until the round is over.
+func (r *Room) revealHints(track models.Track) {
+	revealed := 0
+	for revealed < len(r.opts.HintDelays) {
+		r.mu.Lock()
+		if r.PlayedTracks[len(r.PlayedTracks)-1].Name != track.Name || r.roundOver {
+			r.mu.Unlock()
+			return
+		}
+		elapsed := r.roundElapsed()
+		level := r.hintLevel(elapsed)
+		r.mu.Unlock()
+
+		if level > revealed {
+			revealed = level
+			hint := newHint(track, level)
+			r.publish(RoomEvent{Kind: HintEvent, Hint: &hint})
+			continue
+		}
+
+		// Rounds may be paused, so the time left is checked again on wake up
+		ticker := r.opts.Clock.NewTicker(max(r.opts.HintDelays[level]-elapsed, time.Millisecond))
+		select {
+		case <-ticker.C():
+			ticker.Stop()
+		case <-r.done:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+// validHintDelays sorts the hint delays, keeping one by hint level.
+func validHintDelays(delays []time.Duration) []time.Duration {
+	delays = slices.Clone(delays)
+	slices.Sort(delays)
+	return delays[:min(len(delays), maxHintLevel)]
+}
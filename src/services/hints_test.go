@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"lcor.io/songs/src/models"
+)
+
+func TestNewHint(t *testing.T) {
+	testcases := []struct {
+		level         int
+		title, artist string
+	}{
+		{1, "___'_ ____ __ ___", ""},
+		{2, "D__'_ S___ M_ N__", ""},
+		{3, "D__'_ S___ M_ N__", "Q."},
+	}
+
+	for _, tc := range testcases {
+		hint := newHint(benchmarkTrack, tc.level)
+		if hint.Title != tc.title || hint.Artist != tc.artist {
+			t.Errorf("level %d hint = %+v; want %q and %q", tc.level, hint, tc.title, tc.artist)
+		}
+	}
+}
+
+func TestHints(t *testing.T) {
+	clock := newFakeClock()
+	room := newTestRoom(t, testPlaylist(3), WithClock(clock), WithHints(20*time.Second, 10*time.Second))
+	defer room.Close()
+	events, unsubscribe := room.Events()
+	defer unsubscribe()
+	room.AddPlayer(&models.User{ID: "user-0", Name: "User 0"})
+	track := nextTrack(t, events)
+
+	if _, revealed := room.Hint(); revealed {
+		t.Errorf("hint revealed at the start of the round")
+	}
+
+	// The round ticker and the hints one
+	clock.WaitForTickers(t, 2)
+	clock.Advance(10 * time.Second)
+	if event := nextEvent(t, events, HintEvent); event.Hint == nil || event.Hint.Level != 1 || event.Hint.Title != "_____ _" {
+		t.Fatalf("hint event %+v; want the first hint", event)
+	}
+	if hint, revealed := room.Hint(); !revealed || hint.Level != 1 {
+		t.Errorf("hint = %+v, %v; want the first hint", hint, revealed)
+	}
+
+	// Hints lower the points of found fields, but not the finder bonus
+	result, _ := room.GuessResult("user-0", track.Name)
+	if want := float32(100*(1-hintCost) + 50); result.score != want {
+		t.Errorf("score after a hint = %v; want %v", result.score, want)
+	}
+}
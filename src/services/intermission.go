@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrRoundNotStarted = errors.New("The first round has not started yet")
+	ErrRoundOver       = errors.New("The round is over, the next track is coming")
+)
+
+// completed reports whether the player found the title and every artist of
+// the track.
+func (g GuessResult) completed() bool {
+	if g.Title != Valid {
+		return false
+	}
+	for _, validity := range g.Artists {
+		if validity != Valid {
+			return false
+		}
+	}
+	return true
+}
+
+// done reports whether the player has nothing left to answer on the track:
+// they completed it, or made their choice or guessed its year in the modes
+// allowing a single answer.
+func (g GuessResult) done(mode GameMode) bool {
+	switch mode {
+	case ChoiceMode:
+		return g.choice != ""
+	case YearMode:
+		return g.year != 0 || g.completed()
+	}
+	return g.completed()
+}
+
+// roundComplete reports whether enough players are done with the track to end
+// its round: all of them, or the first ones if the room has a limit. It must
+// be called with the room locked.
+func (r *Room) roundComplete(track string) bool {
+	contestants := r.contestants()
+	completed := 0
+	for _, player := range contestants {
+		if guess, exists := player.Guesses[track]; exists && guess.done(r.opts.Mode) {
+			completed++
+		}
+	}
+	if r.opts.FirstFinders > 0 && completed >= r.opts.FirstFinders {
+		return true
+	}
+	return len(contestants) > 0 && completed == len(contestants)
+}
+
+// endRoundEarly cuts the current round to a short intermission revealing the
+// track once it is complete, returning the event to publish if it did. It must
+// be called with the room locked.
+func (r *Room) endRoundEarly() *RoomEvent {
+	if r.roundOver || r.ticker == nil || !r.finishedAt.IsZero() || len(r.PlayedTracks) == 0 {
+		return nil
+	}
+	current := r.PlayedTracks[len(r.PlayedTracks)-1]
+	if !r.roundComplete(current.Name) {
+		return nil
+	}
+
+	r.roundOver = true
+	r.restartTimer(r.opts.RevealDuration)
+	reveal := r.reveal(current)
+	return &RoomEvent{Kind: RoundEndEvent, Track: current, Reveal: &reveal}
+}
+
+// restartTimer restarts the round ticker for d, dropping a tick it may have
+// sent meanwhile so the next round does not start early. It must be called
+// with the room locked.
+func (r *Room) restartTimer(d time.Duration) {
+	r.ticker.Reset(max(d, time.Millisecond))
+	select {
+	case <-r.ticker.C():
+	default:
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"lcor.io/songs/src/models"
+)
+
+func TestEndRoundEarly(t *testing.T) {
+	testcases := []struct {
+		name         string
+		firstFinders int
+		completing   int
+		want         bool
+	}{
+		{"everyone", 0, 3, true},
+		{"some", 0, 2, false},
+		{"first finders", 2, 2, true},
+		{"too few finders", 2, 1, false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := newFakeClock()
+			room := newTestRoom(t, testPlaylist(3), WithClock(clock), WithFirstFinders(tc.firstFinders), WithRevealDuration(5*time.Second))
+			defer room.Close()
+			events, unsubscribe := room.Events()
+			defer unsubscribe()
+			for i := 0; i < 3; i++ {
+				room.AddPlayer(&models.User{ID: fmt.Sprintf("user-%d", i), Name: fmt.Sprintf("User %d", i)})
+			}
+			track := nextTrack(t, events)
+
+			for i := 0; i < tc.completing; i++ {
+				room.GuessResult(fmt.Sprintf("user-%d", i), track.Name+" "+track.Artists[0].Name)
+			}
+			room.mu.Lock()
+			ended := room.roundOver
+			room.mu.Unlock()
+			if ended != tc.want {
+				t.Fatalf("round over %v; want %v", ended, tc.want)
+			}
+			if !tc.want {
+				return
+			}
+
+			if event := nextEvent(t, events, RoundEndEvent); event.Track.Name != track.Name {
+				t.Errorf("round end event %+v; want %s revealed", event, track.Name)
+			}
+			if _, err := room.GuessResult("user-2", track.Name); err != ErrRoundOver {
+				t.Errorf("guess during the intermission error = %v; want %v", err, ErrRoundOver)
+			}
+
+			// The next round starts after the intermission and lasts a full
+			// round
+			clock.Advance(5 * time.Second)
+			nextTrack(t, events)
+			ticker := room.ticker.(*fakeTicker)
+			clock.mu.Lock()
+			next, period := ticker.next, ticker.period
+			clock.mu.Unlock()
+			if want := clock.Now().Add(room.opts.TrackDuration); !next.Equal(want) || period != room.opts.TrackDuration {
+				t.Errorf("next round ends at %v every %v; want %v every %v", next, period, want, room.opts.TrackDuration)
+			}
+		})
+	}
+}
+
+func TestLastRound(t *testing.T) {
+	testcases := []struct {
+		name  string
+		opts  []roomOptFunc
+		play  func(room *Room)
+		event RoomEventKind
+	}{
+		{"played to its end", nil, func(room *Room) {
+			room.GuessResult("user-0", "queen")
+			room.opts.Clock.(*fakeClock).Advance(room.opts.TrackDuration)
+		}, RevealEvent},
+		{"ended early", nil, func(room *Room) {
+			room.GuessResult("user-0", "dont stop me now queen freddie mercury")
+			room.GuessResult("user-1", "dont stop me now queen freddie mercury")
+		}, RoundEndEvent},
+		{"skipped", nil, func(room *Room) {
+			room.VoteSkip("user-0")
+			room.VoteSkip("user-1")
+		}, SkipEvent},
+		{"eliminating", []roomOptFunc{WithElimination(LowestScoreElimination)}, func(room *Room) {
+			room.GuessResult("user-0", "dont stop me now")
+			room.opts.Clock.(*fakeClock).Advance(room.opts.TrackDuration)
+		}, EliminationEvent},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			room := startRound(t, benchmarkTrack, 2, tc.opts...)
+			defer room.Close()
+			events, unsubscribe := room.Events()
+			defer unsubscribe()
+
+			tc.play(room)
+			event := nextEvent(t, events, tc.event)
+			if tc.event == EliminationEvent && event.Winner != "User 0" {
+				t.Errorf("elimination %+v; want User 0 winning", event)
+			}
+		})
+	}
+}
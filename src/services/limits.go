@@ -0,0 +1,92 @@
+package services
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+// Reasons for a room to refuse a guess
+var (
+	ErrGuessTooLong     = errors.New("Guess is too long")
+	ErrGuessRateLimited = errors.New("Too many guesses, slow down")
+	ErrNoAttemptsLeft   = errors.New("No attempts left for this track")
+)
+
+var guessRejections = []error{
+	ErrGuessTooLong,
+	ErrGuessRateLimited,
+	ErrNoAttemptsLeft,
+	ErrUnknownChoice,
+	ErrAlreadyChosen,
+	ErrChoiceExpected,
+	ErrSpectator,
+	ErrBuzzFirst,
+	ErrRoundOver,
+	ErrNotAYear,
+	ErrYearGuessed,
+}
+
+// guessRejection returns the reason a guess was refused, if the error is one,
+// including when the error was forwarded by another server instance.
+func guessRejection(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, rejection := range guessRejections {
+		if errors.Is(err, rejection) || err.Error() == rejection.Error() {
+			return rejection
+		}
+	}
+	return nil
+}
+
+// notInRoom reports whether the error refused a player who is not in the
+// room, including when the error was forwarded by another server instance.
+func notInRoom(err error) bool {
+	return err != nil && (errors.Is(err, ErrNotInRoom) || err.Error() == ErrNotInRoom.Error())
+}
+
+// admitGuess checks the room limits allow the player to guess, spending one
+// of their attempts. It must be called with the room locked.
+func (r *Room) admitGuess(player *Player, guess string) error {
+	if player.Spectator {
+		return ErrSpectator
+	}
+	if r.roundOver {
+		return ErrRoundOver
+	}
+	if r.opts.Mode == ChoiceMode {
+		return ErrChoiceExpected
+	}
+	if r.opts.Mode == BuzzerMode && !r.buzzer.holds(player.PlayerId) {
+		return ErrBuzzFirst
+	}
+	if r.opts.MaxGuessLength > 0 && utf8.RuneCountInString(guess) > r.opts.MaxGuessLength {
+		return ErrGuessTooLong
+	}
+	if r.opts.MaxAttempts > 0 && player.attempts >= int(r.opts.MaxAttempts) {
+		return ErrNoAttemptsLeft
+	}
+
+	// Players earn tokens at the guess rate, up to the burst, and spend one by
+	// guess
+	if r.opts.GuessRate > 0 {
+		now := r.opts.Clock.Now()
+		burst := max(float32(r.opts.GuessBurst), 1)
+		if player.lastGuessAt.IsZero() {
+			player.guessTokens = burst
+		} else {
+			refill := float32(now.Sub(player.lastGuessAt).Seconds()) * r.opts.GuessRate
+			player.guessTokens = min(player.guessTokens+refill, burst)
+		}
+		player.lastGuessAt = now
+
+		if player.guessTokens < 1 {
+			return ErrGuessRateLimited
+		}
+		player.guessTokens--
+	}
+
+	player.attempts++
+	return nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGuessRateLimit(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 1, WithGuessRate(0.5, 2))
+	clock := room.opts.Clock.(*fakeClock)
+
+	for i, want := range []error{nil, nil, ErrGuessRateLimited} {
+		if _, err := room.GuessResult("user-0", "zzz"); !errors.Is(err, want) {
+			t.Errorf("guess %d error = %v; want %v", i, err, want)
+		}
+	}
+
+	// A token is earned back every two seconds, and a refused guess still
+	// returns the current results
+	clock.Advance(time.Second)
+	if result, err := room.GuessResult("user-0", "queen"); err != ErrGuessRateLimited || result == nil {
+		t.Errorf("GuessResult() = %v, %v; want current results, %v", result, err, ErrGuessRateLimited)
+	}
+	clock.Advance(time.Second)
+	if result, err := room.GuessResult("user-0", "queen"); err != nil || result.Artists["queen"] != Valid {
+		t.Errorf("GuessResult() = %v, %v; want queen found", result, err)
+	}
+}
+
+func TestGuessMaxAttempts(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 1, WithMaxAttempts(2), WithGuessRate(0, 0))
+
+	room.GuessResult("user-0", "zzz")
+	room.GuessResult("user-0", "queen")
+	result, err := room.GuessResult("user-0", "dont stop me now")
+	if err != ErrNoAttemptsLeft || result.Title != Invalid || result.Artists["queen"] != Valid {
+		t.Errorf("GuessResult() = %v, %v; want results of the first guesses, %v", result, err, ErrNoAttemptsLeft)
+	}
+}
+
+func TestGuessMaxLength(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 1, WithMaxGuessLength(20))
+
+	if _, err := room.GuessResult("user-0", strings.Repeat("queen ", 4)); err != ErrGuessTooLong {
+		t.Errorf("error = %v; want %v", err, ErrGuessTooLong)
+	}
+	// Refused guesses do not count as attempts
+	if room.Players["user-0"].attempts != 0 {
+		t.Errorf("attempts = %d; want 0", room.Players["user-0"].attempts)
+	}
+}
+
+func TestGuessNotInRoom(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 1)
+
+	if result, err := room.GuessResult("stranger", "queen"); result != nil || err != ErrNotInRoom {
+		t.Errorf("GuessResult() = %v, %v; want %v", result, err, ErrNotInRoom)
+	}
+}
+
+func TestWrongGuessPenalty(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 1, WithWrongGuessPenalty(25))
+
+	room.GuessResult("user-0", "zzz")
+	if result, _ := room.GuessResult("user-0", "queen"); result.score != 125 {
+		t.Errorf("score = %v; want 125", result.score)
+	}
+}
+
+func TestGuessRejection(t *testing.T) {
+	if err := guessRejection(errors.New(ErrNoAttemptsLeft.Error())); err != ErrNoAttemptsLeft {
+		t.Errorf("guessRejection() = %v; want %v", err, ErrNoAttemptsLeft)
+	}
+	if err := guessRejection(ErrRoomNotFound); err != nil {
+		t.Errorf("guessRejection(%v) = %v; want nil", ErrRoomNotFound, err)
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lcor.io/songs/src/models"
+)
+
+var ErrNoLyrics = errors.New("No lyrics for this track")
+
+// LyricsProvider finds the lyrics of tracks for lyrics rounds.
+type LyricsProvider interface {
+	// Lyrics returns the lines of the lyrics of the track, or ErrNoLyrics.
+	Lyrics(track models.Track) ([]string, error)
+}
+
+// FileLyrics reads lyrics from a directory holding a text file by track,
+// named after the track id.
+type FileLyrics struct {
+	Dir string
+}
+
+func (f FileLyrics) Lyrics(track models.Track) ([]string, error) {
+	// Ids come from the music provider, they must not escape the directory
+	if track.ID == "" || track.ID != filepath.Base(track.ID) {
+		return nil, ErrNoLyrics
+	}
+
+	file, err := os.Open(filepath.Join(f.Dir, track.ID+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoLyrics
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error opening lyrics of track %s: %v", track.ID, err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Error reading lyrics of track %s: %v", track.ID, err)
+	}
+	if len(lines) == 0 {
+		return nil, ErrNoLyrics
+	}
+	return lines, nil
+}
@@ -1,40 +1,291 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"slices"
 	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v3/log"
+	"github.com/google/uuid"
 
 	"lcor.io/songs/src/models"
 )
 
 type mansion struct {
-	mu          sync.Mutex
-	activeRooms map[string]*Room
+	mu           sync.Mutex
+	instance     string
+	backend      Backend
+	lyrics       LyricsProvider
+	activeRooms  map[string]*Room
+	closing      bool
+	closed       chan struct{}
+	stopCommands func()
+	dirty        map[string]*Room // Rooms changed since they were last shared
+	replicas     map[string]*Room // Rooms hosted by other instances, until they change
+	stopSharing  func()
+}
+
+const (
+	// How often the rooms changed are shared through a shared backend, so a
+	// burst of guesses takes a single snapshot
+	roomSaveInterval = time.Second
+
+	// How often the hosted rooms are claimed again, keeping their ownership
+	RoomClaimInterval = 10 * time.Second
+)
+
+var ErrMansionClosing = errors.New("Server is shutting down")
+
+var Mansion = newMansion(NewMemoryBackend())
+
+func newMansion(backend Backend) *mansion {
+	m := &mansion{
+		instance:    uuid.NewString(),
+		activeRooms: map[string]*Room{},
+		closed:      make(chan struct{}),
+		dirty:       map[string]*Room{},
+		replicas:    map[string]*Room{},
+	}
+	m.UseBackend(backend)
+	return m
 }
 
-var Mansion = mansion{activeRooms: map[string]*Room{}}
+// UseBackend sets where rooms, users and events are shared with the other
+// server instances. It must be called before any room is created.
+func (m *mansion) UseBackend(backend Backend) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopCommands != nil {
+		m.stopCommands()
+	}
+
+	m.backend = backend
+	users = backend.Users
+
+	commands, unsubscribe := backend.Bus.Subscribe(instanceTopic(m.instance))
+	m.stopCommands = unsubscribe
+	go m.serveCommands(commands)
+
+	if m.stopSharing != nil {
+		m.stopSharing()
+		m.stopSharing = nil
+	}
+	if backend.Shared {
+		m.stopSharing = m.startSharing()
+	}
+}
 
+// startSharing periodically shares the rooms changed and renews the claims of
+// the hosted rooms in the background. The returned function stops it.
+func (m *mansion) startSharing() (stop func()) {
+	saves := RealClock.NewTicker(roomSaveInterval)
+	claims := RealClock.NewTicker(RoomClaimInterval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-saves.C():
+				m.flushRooms()
+			case <-claims.C():
+				m.renewClaims()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			saves.Stop()
+			claims.Stop()
+			close(done)
+		})
+	}
+}
+
+// UseLyrics sets the provider of the lyrics of the rooms playing lyrics rounds.
+func (m *mansion) UseLyrics(provider LyricsProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lyrics = provider
+}
+
+// HasLyrics reports whether rooms can play lyrics rounds.
+func (m *mansion) HasLyrics() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lyrics != nil
+}
+
+// GetAll returns the rooms hosted by this instance, along with replicas of the
+// rooms hosted by other instances, only meant to be listed.
 func (m *mansion) GetAll() map[string]*Room {
-	return m.activeRooms
+	// The shared rooms are listed without holding the lock, as the backend may
+	// be remote
+	m.mu.Lock()
+	backend := m.backend
+	m.mu.Unlock()
+	snapshots, err := backend.Rooms.GetRooms()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rooms := maps.Clone(m.activeRooms)
+	if err != nil {
+		log.Errorf("Error listing shared rooms: %v", err)
+		return rooms
+	}
+	shared := make(map[string]bool, len(snapshots))
+	for _, snapshot := range snapshots {
+		shared[snapshot.Id] = true
+		if _, exists := rooms[snapshot.Id]; exists {
+			continue
+		}
+		replica, err := m.replica(snapshot, "")
+		if err != nil {
+			log.Errorf("%v", err)
+			continue
+		}
+		rooms[snapshot.Id] = replica
+	}
+	maps.DeleteFunc(m.replicas, func(id string, _ *Room) bool {
+		return !shared[id]
+	})
+	return rooms
+}
+
+// replica returns a replica of a room hosted by another instance, reusing the
+// cached one until the room changes. Replicas without an owner are only meant
+// to be listed. The caller must hold the mansion lock.
+func (m *mansion) replica(snapshot RoomSnapshot, owner string) (*Room, error) {
+	cached, exists := m.replicas[snapshot.Id]
+	if exists && cached.version == snapshot.Version && (owner == "" || cached.remoteOwner == owner) {
+		return cached, nil
+	}
+
+	replica, err := RestoreRoom(snapshot, WithBus(m.backend.Bus), WithLyrics(m.lyrics))
+	if err != nil {
+		return nil, err
+	}
+	replica.remoteOwner = owner
+	m.replicas[snapshot.Id] = replica
+	return replica, nil
 }
 
-func (m *mansion) NewRoom(playlist models.Playlist) *Room {
+// NewRoom creates a room playing the playlist with the options chosen by its
+// creator, and hosts it.
+func (m *mansion) NewRoom(playlist models.Playlist, opts ...roomOptFunc) (*Room, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	newRoom := NewRoom(playlist)
-	m.activeRooms[newRoom.Id] = newRoom
-	return newRoom
+	if m.closing {
+		return nil, ErrMansionClosing
+	}
+
+	opts = append(slices.Clone(opts), WithBus(m.backend.Bus), WithLyrics(m.lyrics))
+	newRoom, err := NewRoom(playlist, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.host(newRoom); err != nil {
+		return nil, err
+	}
+	return newRoom, nil
 }
 
+// AddRoom hosts an existing room, e.g. one restored after a restart. Its
+// players are registered back as users, so they keep their identity through
+// their session.
+func (m *mansion) AddRoom(room *Room) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closing {
+		return ErrMansionClosing
+	}
+
+	room.opts.Bus = m.backend.Bus
+	room.opts.Lyrics = m.lyrics
+	for _, player := range room.Players {
+		RegisterUser(models.User{ID: player.PlayerId, Name: player.Name})
+	}
+	return m.host(room)
+}
+
+// host claims a room for this instance and shares it. The caller must hold
+// the mansion lock.
+func (m *mansion) host(room *Room) error {
+	if owner, err := m.backend.Rooms.ClaimRoom(room.Id, m.instance); err != nil {
+		return fmt.Errorf("Error claiming room %s: %v", room.Id, err)
+	} else if owner != m.instance {
+		return fmt.Errorf("Room %s is already hosted by instance %s", room.Id, owner)
+	}
+	if err := m.backend.Rooms.SaveRoom(room.Snapshot()); err != nil {
+		return fmt.Errorf("Error sharing room %s: %v", room.Id, err)
+	}
+
+	room.mansion = m
+	m.activeRooms[room.Id] = room
+	return nil
+}
+
+// GetRoom returns a room hosted by this instance. A room hosted by another
+// instance is returned as a replica forwarding player actions to its owner,
+// unless its owner left it or stopped renewing its claim, in which case this
+// instance takes it over.
 func (m *mansion) GetRoom(id string) (*Room, error) {
+	m.mu.Lock()
+	if room, exists := m.activeRooms[id]; exists {
+		m.mu.Unlock()
+		return room, nil
+	}
+	backend := m.backend
+	m.mu.Unlock()
+
+	// The shared room is fetched and claimed without holding the lock, as the
+	// backend may be remote
+	snapshot, err := backend.Rooms.GetRoom(id)
+	if err != nil {
+		return nil, ErrRoomNotFound
+	}
+	owner, err := backend.Rooms.ClaimRoom(id, m.instance)
+	if err != nil {
+		return nil, fmt.Errorf("Error claiming room %s: %v", id, err)
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if owner != m.instance {
+		return m.replica(snapshot, owner)
+	}
+	// Another request may have taken the room over meanwhile
 	if room, exists := m.activeRooms[id]; exists {
 		return room, nil
 	}
-	return nil, errors.New("Room not found")
+	if m.closing {
+		m.backend.Rooms.ReleaseRoom(id, m.instance)
+		return nil, ErrMansionClosing
+	}
+	room, err := RestoreRoom(snapshot, WithBus(m.backend.Bus), WithLyrics(m.lyrics))
+	if err != nil {
+		m.backend.Rooms.ReleaseRoom(id, m.instance)
+		return nil, err
+	}
+	log.Infof("Taking over room %s", id)
+	delete(m.replicas, id)
+	room.mansion = m
+	m.activeRooms[id] = room
+	return room, nil
 }
 
 func (m *mansion) RemoveRoom(id string) {
@@ -42,4 +293,246 @@ func (m *mansion) RemoveRoom(id string) {
 	defer m.mu.Unlock()
 
 	delete(m.activeRooms, id)
+	delete(m.dirty, id)
+	if err := m.backend.Rooms.DeleteRoom(id); err != nil {
+		log.Errorf("Error removing shared room %s: %v", id, err)
+	}
+}
+
+// saveRoom marks a hosted room as changed, to be shared on the next flush.
+// Rooms of an in-process backend are only ever read from this instance, so
+// they are not saved.
+func (m *mansion) saveRoom(room *Room) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.backend.Shared {
+		m.dirty[room.Id] = room
+	}
+}
+
+// flushRooms shares the current state of the rooms changed since the last
+// flush.
+func (m *mansion) flushRooms() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, room := range m.dirty {
+		delete(m.dirty, id)
+		// An evicted room must not be shared again
+		if m.activeRooms[id] != room {
+			continue
+		}
+		if err := m.backend.Rooms.SaveRoom(room.Snapshot()); err != nil {
+			log.Errorf("Error sharing room %s: %v", id, err)
+		}
+	}
+}
+
+// renewClaims claims the hosted rooms again, so they are not taken over. A
+// room already taken over, its ownership having expired, e.g. while this
+// instance could not reach the backend, is stopped.
+func (m *mansion) renewClaims() {
+	m.mu.Lock()
+	lost := make([]*Room, 0)
+	for id, room := range m.activeRooms {
+		owner, err := m.backend.Rooms.ClaimRoom(id, m.instance)
+		if err != nil {
+			log.Errorf("Error claiming room %s: %v", id, err)
+			continue
+		}
+		if owner != m.instance {
+			log.Warnf("Room %s was taken over by instance %s", id, owner)
+			delete(m.activeRooms, id)
+			delete(m.dirty, id)
+			lost = append(lost, room)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, room := range lost {
+		room.stop()
+	}
+}
+
+// Closing returns a channel closed once the mansion starts shutting down.
+func (m *mansion) Closing() <-chan struct{} {
+	return m.closed
+}
+
+// Shutdown stops accepting new rooms, saves every unfinished room, then stops
+// them, shares their last state and gives up their ownership so another
+// instance can take them over.
+// Rooms left to save once ctx is done are stopped without being saved.
+func (m *mansion) Shutdown(ctx context.Context, save func(*Room) error) error {
+	m.mu.Lock()
+	if !m.closing {
+		m.closing = true
+		close(m.closed)
+	}
+	rooms := make([]*Room, 0, len(m.activeRooms))
+	for _, room := range m.activeRooms {
+		rooms = append(rooms, room)
+	}
+	m.mu.Unlock()
+
+	errs := make([]error, 0)
+	for _, room := range rooms {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("Error saving rooms: %v", err))
+			break
+		}
+		if _, finishedAt, _ := room.activity(); !finishedAt.IsZero() {
+			continue
+		}
+		if err := save(room); err != nil {
+			errs = append(errs, fmt.Errorf("Error saving room %s: %v", room.Id, err))
+		}
+	}
+
+	for _, room := range rooms {
+		room.stop()
+	}
+	m.mu.Lock()
+	if m.stopSharing != nil {
+		m.stopSharing()
+	}
+	m.mu.Unlock()
+	m.flushRooms()
+
+	for _, room := range rooms {
+		if err := m.backend.Rooms.ReleaseRoom(room.Id, m.instance); err != nil {
+			errs = append(errs, fmt.Errorf("Error releasing room %s: %v", room.Id, err))
+		}
+	}
+
+	m.mu.Lock()
+	m.stopCommands()
+	m.mu.Unlock()
+
+	return errors.Join(errs...)
+}
+
+type ReaperOpts struct {
+	Interval      time.Duration
+	IdleTimeout   time.Duration
+	FinishedGrace time.Duration
+	EmptyTimeout  time.Duration
+	Clock         Clock
+}
+
+type reaperOptFunc func(*ReaperOpts)
+
+func defaultReaperOpts() ReaperOpts {
+	return ReaperOpts{
+		Interval:      time.Minute,
+		IdleTimeout:   30 * time.Minute,
+		FinishedGrace: 5 * time.Minute,
+		EmptyTimeout:  5 * time.Minute,
+		Clock:         RealClock,
+	}
+}
+
+// WithReapInterval sets how often the reaper sweeps the active rooms.
+func WithReapInterval(d time.Duration) reaperOptFunc {
+	return func(o *ReaperOpts) {
+		o.Interval = d
+	}
+}
+
+// WithIdleTimeout sets how long a room can go without any player interaction.
+func WithIdleTimeout(d time.Duration) reaperOptFunc {
+	return func(o *ReaperOpts) {
+		o.IdleTimeout = d
+	}
+}
+
+// WithFinishedGrace sets how long a finished room stays open, so players can
+// look at the final scores.
+func WithFinishedGrace(d time.Duration) reaperOptFunc {
+	return func(o *ReaperOpts) {
+		o.FinishedGrace = d
+	}
+}
+
+// WithEmptyTimeout sets how long a room without any connection is kept, which
+// leaves time for the creator of a room to join it.
+func WithEmptyTimeout(d time.Duration) reaperOptFunc {
+	return func(o *ReaperOpts) {
+		o.EmptyTimeout = d
+	}
+}
+
+func WithReaperClock(c Clock) reaperOptFunc {
+	return func(o *ReaperOpts) {
+		o.Clock = c
+	}
+}
+
+// StartReaper periodically evicts idle, finished and empty rooms in the
+// background. The returned function stops the reaper.
+func (m *mansion) StartReaper(opts ...reaperOptFunc) (stop func()) {
+	opt := defaultReaperOpts()
+	for _, fn := range opts {
+		fn(&opt)
+	}
+
+	ticker := opt.Clock.NewTicker(opt.Interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C():
+				m.reap(opt)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+// reap removes every room matching an eviction rule from the mansion, then
+// shuts them down.
+func (m *mansion) reap(opts ReaperOpts) {
+	now := opts.Clock.Now()
+	evicted := make([]*Room, 0)
+
+	m.mu.Lock()
+	for id, room := range m.activeRooms {
+		lastActivity, finishedAt, connections := room.activity()
+
+		var reason string
+		switch {
+		case !finishedAt.IsZero() && now.Sub(finishedAt) >= opts.FinishedGrace:
+			reason = "finished " + now.Sub(finishedAt).String() + " ago"
+		case connections == 0 && now.Sub(lastActivity) >= opts.EmptyTimeout:
+			reason = "no connection for " + now.Sub(lastActivity).String()
+		case now.Sub(lastActivity) >= opts.IdleTimeout:
+			reason = "idle for " + now.Sub(lastActivity).String()
+		default:
+			continue
+		}
+
+		log.Infof("Evicting room %s: %s", id, reason)
+		delete(m.activeRooms, id)
+		delete(m.dirty, id)
+		evicted = append(evicted, room)
+	}
+	m.mu.Unlock()
+
+	for _, room := range evicted {
+		room.Close()
+		if err := m.backend.Rooms.DeleteRoom(room.Id); err != nil {
+			log.Errorf("Error removing shared room %s: %v", room.Id, err)
+		}
+	}
 }
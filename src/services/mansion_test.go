@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lcor.io/songs/src/models"
+	"lcor.io/songs/src/utils"
+)
+
+func TestMansionReap(t *testing.T) {
+	clock := newFakeClock()
+	opts := defaultReaperOpts()
+	opts.Clock = clock
+
+	empty := newTestRoom(t, testPlaylist(1), WithClock(clock))
+	idle := newTestRoom(t, testPlaylist(1), WithClock(clock))
+	idle.connectionNumber = 1
+	finished := newTestRoom(t, testPlaylist(1), WithClock(clock))
+	finished.connectionNumber = 1
+	finished.finishedAt = clock.Now().Add(time.Minute)
+	active := newTestRoom(t, testPlaylist(1), WithClock(clock))
+	active.connectionNumber = 1
+
+	m := newMansion(NewMemoryBackend())
+	for _, room := range []*Room{empty, idle, finished, active} {
+		if err := m.AddRoom(room); err != nil {
+			t.Fatalf("AddRoom() = %v", err)
+		}
+	}
+
+	steps := []struct {
+		elapsed time.Duration
+		evicted *Room
+	}{
+		{opts.EmptyTimeout, empty},
+		{opts.FinishedGrace, finished},
+		{opts.IdleTimeout, idle},
+	}
+
+	for i, step := range steps {
+		clock.Advance(step.elapsed)
+		active.touch()
+		m.reap(opts)
+
+		if _, err := m.GetRoom(step.evicted.Id); err == nil {
+			t.Errorf("room still active after %s", step.elapsed)
+		}
+		if got, want := len(m.GetAll()), len(steps)-i; got != want {
+			t.Errorf("%d rooms active after %s; want %d", got, step.elapsed, want)
+		}
+		select {
+		case <-step.evicted.Done():
+		default:
+			t.Errorf("evicted room was not closed after %s", step.elapsed)
+		}
+	}
+
+	if _, err := m.GetRoom(active.Id); err != nil {
+		t.Errorf("active room was evicted: %v", err)
+	}
+}
+
+func TestMansionShutdown(t *testing.T) {
+	clock := newFakeClock()
+	playing := newTestRoom(t, testPlaylist(1), WithClock(clock))
+	finished := newTestRoom(t, testPlaylist(1), WithClock(clock))
+	finished.finishedAt = clock.Now()
+
+	m := newMansion(NewMemoryBackend())
+	for _, room := range []*Room{playing, finished} {
+		if err := m.AddRoom(room); err != nil {
+			t.Fatalf("AddRoom() = %v", err)
+		}
+	}
+
+	saved := make([]string, 0)
+	err := m.Shutdown(context.Background(), func(r *Room) error {
+		saved = append(saved, r.Id)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+
+	if len(saved) != 1 || saved[0] != playing.Id {
+		t.Errorf("saved rooms %q; want only %q", saved, playing.Id)
+	}
+	for _, room := range []*Room{playing, finished} {
+		select {
+		case <-room.Done():
+		default:
+			t.Errorf("room %s was not closed", room.Id)
+		}
+	}
+	if _, err := m.NewRoom(testPlaylist(1)); !errors.Is(err, ErrMansionClosing) {
+		t.Errorf("NewRoom() while closing = %v; want %v", err, ErrMansionClosing)
+	}
+}
+
+func TestMansionNewRoomOptions(t *testing.T) {
+	m := newMansion(NewMemoryBackend())
+	m.UseLyrics(FileLyrics{Dir: t.TempDir()})
+
+	room, err := m.NewRoom(testPlaylist(1), WithMatcher(utils.PhoneticMatcher))
+	if err != nil {
+		t.Fatalf("NewRoom() = %v", err)
+	}
+	defer room.Close()
+
+	opts := room.Opts()
+	if len(opts.Matchers) != 1 || opts.Matchers[0].Name != utils.PhoneticMatcher {
+		t.Errorf("matchers %v; want the chosen one", opts.Matchers)
+	}
+	if opts.Bus != m.backend.Bus {
+		t.Errorf("bus %v; want the one of the mansion", opts.Bus)
+	}
+	if opts.Lyrics == nil {
+		t.Errorf("no lyrics provider; want the one of the mansion")
+	}
+}
+
+func TestMansionsShareRooms(t *testing.T) {
+	backend := NewMemoryBackend()
+	backend.Shared = true
+	first, second := newMansion(backend), newMansion(backend)
+	user := models.User{ID: "shared-user", Name: "User"}
+
+	room, err := first.NewRoom(testPlaylist(3))
+	if err != nil {
+		t.Fatalf("NewRoom() = %v", err)
+	}
+
+	// Actions on the second instance are forwarded to the first one, and the
+	// events of the room reach the streams of both
+	replica, err := second.GetRoom(room.Id)
+	if err != nil {
+		t.Fatalf("GetRoom() = %v", err)
+	}
+	if replica.remoteOwner != first.instance {
+		t.Fatalf("replica owned by %q; want %q", replica.remoteOwner, first.instance)
+	}
+	events, unsubscribe := replica.Events()
+	defer unsubscribe()
+
+	replica.AddPlayer(&user)
+	track := nextTrack(t, events)
+	if _, joined := room.Players[user.ID]; !joined {
+		t.Fatal("player did not join the owner room")
+	}
+
+	result, err := replica.GuessResult(user.ID, track.Name)
+	if err != nil || result.Title != Valid {
+		t.Fatalf("GuessResult() = %+v, %v; want a valid title", result, err)
+	}
+	for event := range events {
+		if event.Kind == ScoresEvent {
+			if len(event.Scores) != 1 || event.Scores[0].Score <= 0 {
+				t.Errorf("scores %+v; want a single positive score", event.Scores)
+			}
+			break
+		}
+	}
+
+	// Once the first instance shuts down, the second one takes the room over
+	if err := first.Shutdown(context.Background(), func(*Room) error { return nil }); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+	takenOver, err := second.GetRoom(room.Id)
+	if err != nil {
+		t.Fatalf("GetRoom() after shutdown = %v", err)
+	}
+	if takenOver.remoteOwner != "" || takenOver.Players[user.ID] == nil {
+		t.Errorf("room was not taken over with its players: owner %q, players %v", takenOver.remoteOwner, takenOver.Players)
+	}
+	takenOver.Close()
+}
+
+func TestMansionCachesReplicas(t *testing.T) {
+	backend := NewMemoryBackend()
+	backend.Shared = true
+	first, second := newMansion(backend), newMansion(backend)
+	defer first.Shutdown(context.Background(), func(*Room) error { return nil })
+
+	room, err := first.NewRoom(testPlaylist(3))
+	if err != nil {
+		t.Fatalf("NewRoom() = %v", err)
+	}
+
+	// Replicas are kept while the room does not change
+	listed := second.GetAll()[room.Id]
+	if listed == nil || second.GetAll()[room.Id] != listed {
+		t.Fatalf("listed replica %v; want the same replica listed twice", listed)
+	}
+	replica, err := second.GetRoom(room.Id)
+	if err != nil {
+		t.Fatalf("GetRoom() = %v", err)
+	}
+	if again, _ := second.GetRoom(room.Id); again != replica || second.GetAll()[room.Id] != replica {
+		t.Errorf("replica %p fetched again as %p; want the cached one", replica, again)
+	}
+
+	// and restored again once it changed
+	room.AddPlayer(&models.User{ID: "user-0", Name: "User 0"})
+	first.flushRooms()
+	updated, err := second.GetRoom(room.Id)
+	if err != nil {
+		t.Fatalf("GetRoom() = %v", err)
+	}
+	if updated == replica || len(updated.Players) != 1 {
+		t.Errorf("replica after a change has players %v; want a new replica with the player", updated.Players)
+	}
+}
+
+func TestMansionSavesChangedRooms(t *testing.T) {
+	for _, shared := range []bool{false, true} {
+		backend := NewMemoryBackend()
+		backend.Shared = shared
+		m := newMansion(backend)
+
+		room, err := m.NewRoom(testPlaylist(1))
+		if err != nil {
+			t.Fatalf("NewRoom() = %v", err)
+		}
+		room.AddPlayer(&models.User{ID: "user-0", Name: "User 0"})
+		room.AddPlayer(&models.User{ID: "user-1", Name: "User 1"})
+		m.flushRooms()
+
+		// Only shared backends follow the changes, saving them once
+		snapshot, err := backend.Rooms.GetRoom(room.Id)
+		if err != nil {
+			t.Fatalf("GetRoom() = %v", err)
+		}
+		if saved := len(snapshot.Players) == 2; saved != shared {
+			t.Errorf("shared %v: saved players %v; want saved %v", shared, snapshot.Players, shared)
+		}
+		if len(m.dirty) != 0 {
+			t.Errorf("shared %v: rooms %v left to save after a flush", shared, m.dirty)
+		}
+
+		if err := m.Shutdown(context.Background(), func(*Room) error { return nil }); err != nil {
+			t.Fatalf("Shutdown() = %v", err)
+		}
+	}
+}
+
+func TestMansionLosesExpiredRooms(t *testing.T) {
+	backend := NewMemoryBackend()
+	m := newMansion(backend)
+	kept, err := m.NewRoom(testPlaylist(1))
+	if err != nil {
+		t.Fatalf("NewRoom() = %v", err)
+	}
+	lost, err := m.NewRoom(testPlaylist(1))
+	if err != nil {
+		t.Fatalf("NewRoom() = %v", err)
+	}
+
+	// Another instance took the room over once its claim expired
+	backend.Rooms.ReleaseRoom(lost.Id, m.instance)
+	backend.Rooms.ClaimRoom(lost.Id, "other")
+	m.renewClaims()
+
+	if _, hosted := m.activeRooms[kept.Id]; !hosted {
+		t.Error("renewed room is not hosted anymore")
+	}
+	if _, hosted := m.activeRooms[lost.Id]; hosted {
+		t.Error("room taken over is still hosted")
+	}
+	select {
+	case <-lost.Done():
+	default:
+		t.Error("room taken over is still playing")
+	}
+	kept.Close()
+}
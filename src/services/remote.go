@@ -0,0 +1,179 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v3/log"
+	"github.com/google/uuid"
+
+	"lcor.io/songs/src/models"
+)
+
+type roomAction string
+
+const (
+	joinAction    roomAction = "join"
+	connectAction roomAction = "connect"
+	leaveAction   roomAction = "leave"
+	guessAction   roomAction = "guess"
+	chooseAction  roomAction = "choose"
+	teamAction    roomAction = "team"
+	chatAction    roomAction = "chat"
+	buzzAction    roomAction = "buzz"
+	skipAction    roomAction = "skip"
+	orderAction   roomAction = "order"
+)
+
+// roomCommand is a player action forwarded to the server instance owning the
+// room.
+type roomCommand struct {
+	Id      string
+	RoomId  string
+	Action  roomAction
+	User    models.User
+	Nonce   uint8
+	Guess   string
+	Choice  string
+	Team    string
+	Message string
+	Order   []string
+}
+
+type roomReply struct {
+	Nonce  uint8
+	Result GuessSnapshot
+	Error  string
+}
+
+// Maximum time to wait for the owner of a room to handle a forwarded action
+const forwardTimeout = 5 * time.Second
+
+func instanceTopic(instance string) string {
+	return "instance:" + instance
+}
+
+func replyTopic(id string) string {
+	return "reply:" + id
+}
+
+// forward sends a player action to the instance owning the room and waits for
+// its reply.
+func (r *Room) forward(cmd roomCommand) (roomReply, error) {
+	cmd.Id = uuid.NewString()
+	cmd.RoomId = r.Id
+
+	replies, unsubscribe := r.opts.Bus.Subscribe(replyTopic(cmd.Id))
+	defer unsubscribe()
+
+	msg, err := json.Marshal(cmd)
+	if err != nil {
+		return roomReply{}, fmt.Errorf("Error serializing command: %v", err)
+	}
+	if err := r.opts.Bus.Publish(instanceTopic(r.remoteOwner), msg); err != nil {
+		return roomReply{}, fmt.Errorf("Error publishing command: %v", err)
+	}
+
+	select {
+	case msg, ok := <-replies:
+		if !ok {
+			return roomReply{}, errors.New("Reply subscription closed")
+		}
+		reply := roomReply{}
+		if err := json.Unmarshal(msg, &reply); err != nil {
+			return roomReply{}, fmt.Errorf("Error deserializing reply: %v", err)
+		}
+		if reply.Error != "" {
+			return reply, errors.New(reply.Error)
+		}
+		return reply, nil
+	case <-time.After(forwardTimeout):
+		return roomReply{}, fmt.Errorf("Instance %s did not reply in time", r.remoteOwner)
+	}
+}
+
+// serveCommands handles the player actions forwarded by other instances to
+// the rooms this instance owns.
+func (m *mansion) serveCommands(commands <-chan []byte) {
+	for msg := range commands {
+		go m.handleCommand(msg)
+	}
+}
+
+func (m *mansion) handleCommand(msg []byte) {
+	cmd := roomCommand{}
+	if err := json.Unmarshal(msg, &cmd); err != nil {
+		log.Errorf("Error deserializing command: %v", err)
+		return
+	}
+
+	reply := roomReply{}
+	m.mu.Lock()
+	room, exists := m.activeRooms[cmd.RoomId]
+	bus := m.backend.Bus
+	m.mu.Unlock()
+
+	if !exists {
+		reply.Error = ErrRoomNotFound.Error()
+	} else {
+		switch cmd.Action {
+		case joinAction:
+			room.AddPlayer(&cmd.User)
+		case connectAction:
+			reply.Nonce = room.Connect(cmd.User.ID)
+		case leaveAction:
+			room.RemovePlayer(cmd.User.ID, cmd.Nonce)
+		case guessAction:
+			result, err := room.GuessResult(cmd.User.ID, cmd.Guess)
+			if result != nil {
+				reply.Result = result.snapshot()
+			}
+			if err != nil {
+				reply.Error = err.Error()
+			}
+		case chooseAction:
+			result, err := room.Choose(cmd.User.ID, cmd.Choice)
+			if result != nil {
+				reply.Result = result.snapshot()
+			}
+			if err != nil {
+				reply.Error = err.Error()
+			}
+		case teamAction:
+			if err := room.JoinTeam(cmd.User.ID, cmd.Team); err != nil {
+				reply.Error = err.Error()
+			}
+		case chatAction:
+			if err := room.SendTeamMessage(cmd.User.ID, cmd.Message); err != nil {
+				reply.Error = err.Error()
+			}
+		case buzzAction:
+			if err := room.Buzz(cmd.User.ID); err != nil {
+				reply.Error = err.Error()
+			}
+		case skipAction:
+			if err := room.VoteSkip(cmd.User.ID); err != nil {
+				reply.Error = err.Error()
+			}
+		case orderAction:
+			points, err := room.OrderTracks(cmd.User.ID, cmd.Order)
+			reply.Result.Score = points
+			if err != nil {
+				reply.Error = err.Error()
+			}
+		default:
+			reply.Error = fmt.Sprintf("Unknown action %q", cmd.Action)
+		}
+	}
+
+	res, err := json.Marshal(reply)
+	if err != nil {
+		log.Errorf("Error serializing reply: %v", err)
+		return
+	}
+	if err := bus.Publish(replyTopic(cmd.Id), res); err != nil {
+		log.Errorf("Error publishing reply: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"lcor.io/songs/src/models"
+	"lcor.io/songs/src/utils"
+)
+
+// Find is a field of a track found by a player.
+type Find struct {
+	Player string
+	Field  string // Label of the field
+	Rank   int    // Rank of the player among the ones who found the field
+	Time   time.Duration
+}
+
+// Reveal tells the players the answer of a round once it is over, and how
+// they did.
+type Reveal struct {
+	Track  models.Track
+	Finds  []Find  // In the order the fields were found
+	Points []Score // Points earned on the track, best first
+}
+
+// fieldLabel returns the name shown for a field of the track.
+func fieldLabel(track models.Track, field string) string {
+	switch field {
+	case titleField:
+		return "Title"
+	case albumField:
+		return "Album"
+	case yearField:
+		return "Year"
+	}
+	for _, artist := range track.Artists {
+		normalized := utils.Normalize(artist.Name)
+		if field == artistField(normalized) || field == featuredField(normalized) {
+			return artist.Name
+		}
+	}
+	return strings.TrimPrefix(field, "artist:")
+}
+
+// reveal builds the reveal of the round of the track. It must be called with
+// the room locked.
+func (r *Room) reveal(track models.Track) Reveal {
+	reveal := Reveal{Track: track}
+
+	for field, finders := range r.finders[track.Name] {
+		label := fieldLabel(track, field)
+		for i, finder := range finders {
+			reveal.Finds = append(reveal.Finds, Find{finder.Name, label, i + 1, finder.Elapsed})
+		}
+	}
+	slices.SortStableFunc(reveal.Finds, func(a, b Find) int {
+		if a.Time != b.Time {
+			return cmp.Compare(a.Time, b.Time)
+		}
+		return cmp.Compare(a.Field, b.Field)
+	})
+
+	for _, player := range r.Players {
+		if guess, exists := player.Guesses[track.Name]; exists {
+			reveal.Points = append(reveal.Points, Score{player.Name, guess.score})
+		}
+	}
+	slices.SortFunc(reveal.Points, func(a, b Score) int {
+		if a.Score != b.Score {
+			return cmp.Compare(b.Score, a.Score)
+		}
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return reveal
+}
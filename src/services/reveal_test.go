@@ -0,0 +1,36 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestReveal(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 3, WithFinderBonuses(10))
+	clock := room.opts.Clock.(*fakeClock)
+
+	room.GuessResult("user-0", "queen")
+	clock.Advance(2 * time.Second)
+	room.GuessResult("user-1", "dont stop me now")
+	clock.Advance(2 * time.Second)
+	room.GuessResult("user-2", "queen dont stop me now")
+
+	room.mu.Lock()
+	reveal := room.reveal(benchmarkTrack)
+	room.mu.Unlock()
+
+	wantFinds := []Find{
+		{"User 0", "Queen", 1, 0},
+		{"User 1", "Title", 1, 2 * time.Second},
+		{"User 2", "Queen", 2, 4 * time.Second},
+		{"User 2", "Title", 2, 4 * time.Second},
+	}
+	if !slices.Equal(reveal.Finds, wantFinds) {
+		t.Errorf("finds = %v; want %v", reveal.Finds, wantFinds)
+	}
+	wantPoints := []Score{{"User 2", 200}, {"User 0", 110}, {"User 1", 110}}
+	if !slices.Equal(reveal.Points, wantPoints) {
+		t.Errorf("points = %v; want %v", reveal.Points, wantPoints)
+	}
+}
@@ -1,10 +1,11 @@
 package services
 
 import (
+	"cmp"
+	"fmt"
 	"maps"
 	"math/rand"
 	"slices"
-	"strings"
 	"sync"
 	"time"
 
@@ -24,18 +25,33 @@ const (
 )
 
 type GuessResult struct {
-	Title   ResultValidity
-	Artists map[string]ResultValidity
-	score   float32
+	Title        ResultValidity
+	Artists      map[string]ResultValidity
+	Fields       map[string]ResultValidity // Optional targets, like the album
+	score        float32
+	bonus        float32            // Earned by finding fields before other players
+	ranks        map[string]int     // Order in which the player found each field
+	points       map[string]float32 // Points earned by each found field
+	penalty      float32            // Lost by wrong guesses
+	responseTime time.Duration      // Time between the start of the round and the last guess
+	choice       string             // Option chosen in multiple-choice rounds
+	year         int                // Release year guessed in year rounds
 }
 
 type Player struct {
-	Id       string
-	Name     string
-	PlayerId string
-	Guesses  map[string]*GuessResult
-	score    float32
-	Nonce    uint8 // Used to reconnect a user if a leave a room
+	Id        string
+	Name      string
+	PlayerId  string
+	Team      string
+	Spectator bool // Eliminated players keep watching the game without guessing
+	Guesses   map[string]*GuessResult
+	score     float32
+	Nonce     uint8 // Used to reconnect a user if a leave a room
+
+	// Guesses sent during the current round, and tokens left to guess again
+	attempts    int
+	guessTokens float32
+	lastGuessAt time.Time
 }
 
 type RoomOpts struct {
@@ -43,6 +59,31 @@ type RoomOpts struct {
 	GuessValidityThreshold int8
 	GuessPartialThreshold  int8
 	MaxPlayerNumber        int8
+	Clock                  Clock    `json:"-"`
+	Bus                    EventBus `json:"-"`
+	Seed                   int64
+	Matchers               []utils.MatcherWeight
+	FinderBonuses          []float32
+	Scoring                string
+	ScoreDecay             ScoreDecay
+	WrongGuessPenalty      float32
+	GuessRate              float32 // Guesses per second, 0 for no limit
+	GuessBurst             int8
+	MaxAttempts            int8 // Guesses per round, 0 for no limit
+	MaxGuessLength         int  // In characters, 0 for no limit
+	Targets                []Target
+	YearTolerance          int
+	Mode                   GameMode
+	Teams                  []string
+	Elimination            EliminationRule
+	BuzzerAnswerTime       time.Duration   // Time to answer after buzzing
+	HintDelays             []time.Duration // Time in the round at which each hint is revealed
+	SkipMajority           float32         // Share of the players to exceed to skip a track, 1 to never skip
+	FirstFinders           int             // Players completing a track ending its round, 0 to wait for everyone
+	RevealDuration         time.Duration   // Intermission after a round ended early
+	RoundTypes             []RoundType     // Drawn for each round, audio only if empty
+	Lyrics                 LyricsProvider  `json:"-"`
+	ChronologyRound        bool            // End with ordering the played tracks by release date
 }
 
 type roomOptFunc func(*RoomOpts)
@@ -53,6 +94,18 @@ func defaultOpts() RoomOpts {
 		GuessValidityThreshold: 80,
 		GuessPartialThreshold:  50,
 		MaxPlayerNumber:        15,
+		Matchers:               []utils.MatcherWeight{{Name: utils.LevenshteinMatcher, Weight: 1}},
+		FinderBonuses:          []float32{50, 25, 15},
+		GuessRate:              1,
+		GuessBurst:             5,
+		MaxGuessLength:         maxGuessLength,
+		YearTolerance:          2,
+		Mode:                   TextMode,
+		BuzzerAnswerTime:       10 * time.Second,
+		SkipMajority:           0.5,
+		RevealDuration:         5 * time.Second,
+		Clock:                  RealClock,
+		Bus:                    localBus,
 	}
 }
 
@@ -80,6 +133,201 @@ func WithMaxPlayerNumber(n int8) roomOptFunc {
 	}
 }
 
+// WithMatcher compares guesses to answers with a single algorithm.
+func WithMatcher(name string) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.Matchers = []utils.MatcherWeight{{Name: name, Weight: 1}}
+	}
+}
+
+// WithMatchers compares guesses to answers with a weighted average of several
+// algorithms.
+func WithMatchers(weights ...utils.MatcherWeight) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.Matchers = weights
+	}
+}
+
+// WithFinderBonuses sets the bonus earned by the first, second, etc. players
+// finding each field of a track.
+func WithFinderBonuses(bonuses ...float32) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.FinderBonuses = bonuses
+	}
+}
+
+// WithScoring selects the strategy scoring the guesses of the players.
+func WithScoring(name string) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.Scoring = name
+	}
+}
+
+// WithScoreDecay decreases the points of the fields found over the round,
+// rewarding fast answers, whatever the scoring strategy.
+func WithScoreDecay(d ScoreDecay) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.ScoreDecay = d
+	}
+}
+
+// WithWrongGuessPenalty takes points away from the guesses matching nothing,
+// whatever the scoring strategy.
+func WithWrongGuessPenalty(points float32) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.WrongGuessPenalty = points
+	}
+}
+
+// WithGuessRate limits the guesses of each player to rate per second, allowing
+// bursts of up to burst guesses. A zero rate disables the limit.
+func WithGuessRate(rate float32, burst int8) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.GuessRate = rate
+		o.GuessBurst = burst
+	}
+}
+
+// WithMaxAttempts limits the guesses of each player during a round. Zero
+// disables the limit.
+func WithMaxAttempts(n int8) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.MaxAttempts = n
+	}
+}
+
+// WithMaxGuessLength refuses guesses longer than n characters. Zero disables
+// the limit, longer guesses being truncated instead.
+func WithMaxGuessLength(n int) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.MaxGuessLength = n
+	}
+}
+
+// WithTargets lets players guess optional fields of the tracks, earning part
+// of the points of a title or artist.
+func WithTargets(targets ...Target) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.Targets = targets
+	}
+}
+
+// WithYearTolerance accepts release years guessed up to n years off.
+func WithYearTolerance(n int) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.YearTolerance = n
+	}
+}
+
+// WithMode sets the way players answer each round.
+func WithMode(mode GameMode) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.Mode = mode
+	}
+}
+
+// WithTeams plays the room in teams, players joining the smallest one unless
+// they pick another.
+func WithTeams(teams ...string) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.Teams = teams
+	}
+}
+
+// WithElimination eliminates players after each round according to the rule.
+func WithElimination(rule EliminationRule) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.Elimination = rule
+	}
+}
+
+// WithBuzzerAnswerTime sets the time players have to answer after buzzing.
+func WithBuzzerAnswerTime(d time.Duration) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.BuzzerAnswerTime = d
+	}
+}
+
+// WithHints reveals hints on the track after each delay of a round, each one
+// lowering the points still available.
+func WithHints(delays ...time.Duration) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.HintDelays = delays
+	}
+}
+
+// WithEvenHints reveals n hints spread evenly over each round. It must come
+// after any track duration option.
+func WithEvenHints(n int) roomOptFunc {
+	return func(o *RoomOpts) {
+		delays := make([]time.Duration, 0, n)
+		for level := 1; level <= n; level++ {
+			delays = append(delays, o.TrackDuration*time.Duration(level)/time.Duration(n+1))
+		}
+		o.HintDelays = delays
+	}
+}
+
+// WithSkipMajority sets the share of the players to exceed to skip a track.
+func WithSkipMajority(share float32) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.SkipMajority = share
+	}
+}
+
+// WithFirstFinders ends rounds once n players found the title and every
+// artist of the track.
+func WithFirstFinders(n int) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.FirstFinders = n
+	}
+}
+
+// WithRevealDuration sets the intermission revealing a track after its round
+// ended early.
+func WithRevealDuration(d time.Duration) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.RevealDuration = d
+	}
+}
+
+// WithRoundTypes mixes rounds of these types in the game, drawing one for each
+// track.
+func WithRoundTypes(types ...RoundType) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.RoundTypes = types
+	}
+}
+
+// WithLyrics sets the provider of the lyrics shown in lyrics rounds.
+func WithLyrics(provider LyricsProvider) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.Lyrics = provider
+	}
+}
+
+// WithClock replaces the clock driving the room rounds.
+func WithClock(c Clock) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.Clock = c
+	}
+}
+
+// WithBus sets the bus the room events are published on.
+func WithBus(b EventBus) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.Bus = b
+	}
+}
+
+// WithSeed sets the seed of the room random source, so the same seed on the
+// same playlist always yields the same track order.
+func WithSeed(seed int64) roomOptFunc {
+	return func(o *RoomOpts) {
+		o.Seed = seed
+	}
+}
+
 type Room struct {
 	Id string
 
@@ -87,20 +335,44 @@ type Room struct {
 
 	Playlist     *models.Playlist
 	PlayedTracks []models.Track
-	CurrentTrack chan models.Track
 	Players      map[string]*Player
-	Scores       chan []struct {
-		Id    string
-		Score float32
-	}
+
+	// Mansion hosting the room, and owner instance if the room is only a
+	// replica of a room hosted by another server instance
+	mansion     *mansion
+	remoteOwner string
+	version     uint64 // Changes shared with the other instances
 
 	connectionNumber uint8
-	done             chan bool
-	ticker           *time.Ticker
+	started          bool
+	roundStartedAt   time.Time
+	resumeElapsed    time.Duration // Time elapsed in the round a restored room resumes
+	roundOver        bool          // The round ended early, its track being revealed
+	round            Round
+	lastActivity     time.Time
+	finishedAt       time.Time
+	done             chan struct{}
+	closeOnce        sync.Once
+	ticker           Ticker
+	rng              *rand.Rand
+	finders          map[string]map[string][]Finder // Players who found each field of each track, in order
+	answers          *trackAnswers
+	choices          *roundChoices
+	buzzer           *roundBuzzer
+	teams            map[string]string // Team of every player who joined, kept when they leave
+	rounds           []RoundScores
+	skipVotes        map[string]bool  // Players who voted to skip the current track
+	skips            chan string      // Tracks voted to be skipped
+	skipped          []string         // Ids of the skipped tracks
+	chronology       *chronologyRound // Final round, once every track was played
+	matcher          utils.Matcher
+	scoring          ScoringStrategy
 	mu               sync.Mutex
 }
 
-func NewRoom(playlist models.Playlist, opts ...roomOptFunc) *Room {
+// NewRoom creates a room playing the playlist. Options naming an unknown
+// scoring strategy are refused.
+func NewRoom(playlist models.Playlist, opts ...roomOptFunc) (*Room, error) {
 	opt := defaultOpts()
 
 	// Apply room options in order
@@ -108,6 +380,41 @@ func NewRoom(playlist models.Playlist, opts ...roomOptFunc) *Room {
 		fn(&opt)
 	}
 
+	// Without an explicit seed, draw one from the clock and keep it so the
+	// game can still be reproduced afterwards
+	if opt.Seed == 0 {
+		opt.Seed = opt.Clock.Now().UnixNano()
+	}
+
+	matcher, err := utils.NewMatcher(opt.Matchers...)
+	if err != nil {
+		log.Warnf("%v, falling back to %s", err, utils.LevenshteinMatcher)
+		matcher = utils.Levenshtein{}
+	}
+	if !opt.ScoreDecay.Valid() {
+		log.Warnf("Unknown score decay %q, ignoring it", opt.ScoreDecay)
+		opt.ScoreDecay = NoDecay
+	}
+	if !opt.Mode.Valid() {
+		log.Warnf("Unknown game mode %q, falling back to %s", opt.Mode, TextMode)
+		opt.Mode = TextMode
+	}
+	if !opt.Elimination.Valid() {
+		log.Warnf("Unknown elimination rule %q, ignoring it", opt.Elimination)
+		opt.Elimination = NoElimination
+	}
+	// Year rounds show the release year next to the results of the players
+	if opt.Mode == YearMode && !slices.Contains(opt.Targets, YearTarget) {
+		opt.Targets = append(slices.Clone(opt.Targets), YearTarget)
+	}
+	opt.Teams = validTeams(opt.Teams)
+	opt.HintDelays = validHintDelays(opt.HintDelays)
+	opt.RoundTypes = validRoundTypes(opt.RoundTypes)
+	scoring, err := NewScoring(opt)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Room{
 		Id: uuid.NewString(),
 
@@ -115,230 +422,473 @@ func NewRoom(playlist models.Playlist, opts ...roomOptFunc) *Room {
 
 		Playlist:     &playlist,
 		PlayedTracks: make([]models.Track, 0, len(playlist.Tracks)),
-		CurrentTrack: make(chan models.Track, opt.MaxPlayerNumber),
 		Players:      make(map[string]*Player),
-		Scores: make(chan []struct {
-			Id    string
-			Score float32
-		}, opt.MaxPlayerNumber),
 
 		connectionNumber: 0,
-		done:             make(chan bool),
+		lastActivity:     opt.Clock.Now(),
+		done:             make(chan struct{}),
+		rng:              rand.New(rand.NewSource(opt.Seed)),
+		finders:          map[string]map[string][]Finder{},
+		teams:            map[string]string{},
+		skips:            make(chan string, 1),
+		matcher:          matcher,
+		scoring:          scoring,
+	}, nil
+}
+
+// Opts returns the options the room was created with.
+func (r *Room) Opts() RoomOpts {
+	return r.opts
+}
+
+// Seed returns the seed of the room random source.
+func (r *Room) Seed() int64 {
+	return r.opts.Seed
+}
+
+// Done returns a channel closed once the room is shut down.
+func (r *Room) Done() <-chan struct{} {
+	return r.done
+}
+
+// Close shuts the room down, stopping its game loop and ending the streams
+// of its players. It is safe to call several times.
+func (r *Room) Close() {
+	r.stop()
+	r.publish(RoomEvent{Kind: ClosedEvent})
+}
+
+// stop stops the room game loop, leaving its streams open so the room can be
+// taken over by another server instance.
+func (r *Room) stop() {
+	r.closeOnce.Do(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if r.ticker != nil {
+			r.ticker.Stop()
+		}
+		close(r.done)
+	})
+}
+
+// Played returns a copy of the tracks played so far, the last one being the
+// current track.
+func (r *Room) Played() []models.Track {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.PlayedTracks)
+}
+
+// changed shares the new state of the room with the other server instances.
+// It must be called without the room locked.
+func (r *Room) changed() {
+	select {
+	case <-r.done:
+		// A closed room is not shared anymore
+		return
+	default:
 	}
+
+	r.mu.Lock()
+	r.version++
+	r.mu.Unlock()
+
+	if r.mansion != nil {
+		r.mansion.saveRoom(r)
+	}
+}
+
+// touch records a player interaction with the room.
+func (r *Room) touch() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastActivity = r.opts.Clock.Now()
+}
+
+// activity returns when a player last interacted with the room, when its game
+// ended (zero while still playing) and its number of open connections.
+func (r *Room) activity() (lastActivity, finishedAt time.Time, connections uint8) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lastActivity, r.finishedAt, r.connectionNumber
 }
 
 func (r *Room) Launch() {
+	// Once every track has been played, the room stays open for the players
+	// to see the final scores until the mansion reaper evicts it
 	defer func() {
-		for _, player := range r.Players {
-			r.RemovePlayer(player.Id, player.Nonce)
-		}
+		r.mu.Lock()
+		r.ticker.Stop()
+		r.finishedAt = r.opts.Clock.Now()
+		r.mu.Unlock()
 	}()
 
 	playlistTracks := r.Playlist.Tracks
-	r.ticker = time.NewTicker(r.opts.TrackDuration)
 
-	processNewTrack := func() {
-		// Select a track random track from playlist not in already played tracks
-		newTrack := playlistTracks[rand.Intn(len(playlistTracks))]
-		newTrackAlreadyPlayed := slices.ContainsFunc(r.PlayedTracks, func(t models.Track) bool {
-			return t.Name == newTrack.Name
+	// Draw the whole play order up front, so it only depends on the room seed.
+	// Tracks sharing a name with an already drawn one are skipped, as well as
+	// tracks without a release year in year rounds.
+	order := make([]int, 0, len(playlistTracks))
+	for _, idx := range r.rng.Perm(len(playlistTracks)) {
+		alreadyDrawn := slices.ContainsFunc(order, func(i int) bool {
+			return playlistTracks[i].Name == playlistTracks[idx].Name
+		})
+		alreadyPlayed := slices.ContainsFunc(r.PlayedTracks, func(t models.Track) bool {
+			return t.Name == playlistTracks[idx].Name
 		})
-		for newTrackAlreadyPlayed {
-			newTrack = playlistTracks[rand.Intn(len(playlistTracks))]
-			newTrackAlreadyPlayed = slices.ContainsFunc(r.PlayedTracks, func(t models.Track) bool {
-				return t.Name == newTrack.Name
-			})
+		undated := r.opts.Mode == YearMode && releaseYear(playlistTracks[idx]) == 0
+		if !alreadyDrawn && !alreadyPlayed && !undated {
+			order = append(order, idx)
 		}
+	}
 
-		r.PlayedTracks = append(r.PlayedTracks, newTrack)
+	// A restored room resumes its current round where it was left, however
+	// long the players took to come back
+	r.mu.Lock()
+	firstRound := r.opts.TrackDuration
+	resumed := len(r.PlayedTracks) > 0
+	if resumed {
+		r.roundStartedAt = r.opts.Clock.Now().Add(-r.resumeElapsed)
+		firstRound = max(r.opts.TrackDuration-r.resumeElapsed, time.Millisecond)
+	}
+	r.ticker = r.opts.Clock.NewTicker(firstRound)
+	r.mu.Unlock()
+
+	if played := r.Played(); resumed && len(r.opts.HintDelays) > 0 {
+		go r.revealHints(played[len(played)-1])
+	}
+
+	processNewTrack := func() bool {
+		newTrack := playlistTracks[order[0]]
+		order = order[1:]
+		round := r.newRound(newTrack)
+
+		// Close the previous round, the game ending with the last player
+		// standing
+		r.mu.Lock()
+		roundEvents, over := r.endRound()
+		if over {
+			r.mu.Unlock()
+			for _, event := range roundEvents {
+				r.publish(event)
+			}
+			r.changed()
+			return false
+		}
 
 		// Create a new set of results for each player in the room and send the
 		// new track
-		r.mu.Lock()
+		r.PlayedTracks = append(r.PlayedTracks, newTrack)
+		r.roundStartedAt = r.opts.Clock.Now()
+		answers := r.answersFor(newTrack)
 		for _, player := range r.Players {
-			newGuess := GuessResult{
-				Title:   Invalid,
-				Artists: make(map[string]ResultValidity, len(newTrack.Artists)),
-			}
-			for _, artist := range newTrack.Artists {
-				newGuess.Artists[utils.Normalize(artist.Name)] = Invalid
-			}
-			player.Guesses[newTrack.Name] = &newGuess
+			player.attempts = 0
+			player.Guesses[newTrack.Name] = answers.newResult()
 		}
-		for i := uint8(0); i < r.connectionNumber; i++ {
-			r.CurrentTrack <- newTrack
+		r.round = round
+		r.skipVotes = nil
+		r.roundOver = false
+		r.resetBuzzer()
+
+		// Early ends and buzzes change the period of the ticker, so each round
+		// restarts it from its own start
+		r.restartTimer(r.opts.TrackDuration)
+		var choices []Choice
+		if r.opts.Mode == ChoiceMode {
+			r.choices = r.drawChoices(newTrack)
+			choices = slices.Clone(r.choices.Options)
 		}
+		playedTracks := slices.Clone(r.PlayedTracks)
 		r.mu.Unlock()
+
+		for _, event := range roundEvents {
+			r.publish(event)
+		}
+		r.publish(RoomEvent{Kind: TrackEvent, Track: newTrack, PlayedTracks: playedTracks, Choices: choices, Round: &round})
+		r.changed()
+		if len(r.opts.HintDelays) > 0 {
+			go r.revealHints(newTrack)
+		}
+		return true
 	}
 
-	for i := 0; i < len(playlistTracks); i++ {
-		if i == 0 {
+	for i := 0; len(order) > 0; i++ {
+		if i == 0 && !resumed {
 			processNewTrack()
 			continue
 		}
 		select {
 		case <-r.done:
 			return
-		case <-r.ticker.C:
-			processNewTrack()
+		case <-r.ticker.C():
+			if !processNewTrack() {
+				return
+			}
+		case track := <-r.skips:
+			// Votes arriving as the round ends are ignored
+			if !r.skipTrack(track) {
+				continue
+			}
+			if !processNewTrack() {
+				return
+			}
 		}
 	}
+
+	// The last round plays to its end like the others, closing the game
+	if !r.finishLastRound() {
+		return
+	}
+	if r.opts.ChronologyRound {
+		r.playChronology()
+	}
 }
 
-func (r *Room) GuessResult(playerId, guess string) *GuessResult {
-	currentTrack := r.PlayedTracks[len(r.PlayedTracks)-1]
+// finishLastRound waits for the last round to end, or for the players to skip
+// its track, and closes it. It returns whether the game goes on to a final
+// round, which it does not once a single player remains.
+func (r *Room) finishLastRound() bool {
+	if len(r.Played()) == 0 {
+		return false
+	}
+	for ended := false; !ended; {
+		select {
+		case <-r.done:
+			return false
+		case <-r.ticker.C():
+			ended = true
+		case track := <-r.skips:
+			ended = r.skipTrack(track)
+		}
+	}
+
+	r.mu.Lock()
+	roundEvents, over := r.endRound()
+	r.roundOver = true
+	r.mu.Unlock()
+
+	for _, event := range roundEvents {
+		r.publish(event)
+	}
+	r.changed()
+	return !over
+}
 
-	// Normalize inputs for comparison
-	normalizedGuess := utils.Normalize(guess)
-	normalizedTitle := utils.Normalize(currentTrack.Name)
-	normalizedArtists := make([]string, len(currentTrack.Artists))
-	for i, artist := range currentTrack.Artists {
-		normalizedArtists[i] = utils.Normalize(artist.Name)
+// GuessResult judges a guess of the player on the current track. Guesses
+// refused by the room limits return the unchanged result of the player along
+// with the reason they were refused.
+func (r *Room) GuessResult(playerId, guess string) (*GuessResult, error) {
+	if r.remoteOwner != "" {
+		reply, err := r.forward(roomCommand{Action: guessAction, User: models.User{ID: playerId}, Guess: guess})
+		if rejection := guessRejection(err); rejection != nil {
+			return reply.Result.restore(), rejection
+		}
+		if notInRoom(err) {
+			return nil, ErrNotInRoom
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error forwarding guess to room %s: %v", r.Id, err)
+		}
+		return reply.Result.restore(), nil
+	}
+	if r.opts.Mode == YearMode {
+		return r.guessYear(playerId, guess)
 	}
+	defer r.changed()
 
-	// The player can guess the artists and the title at the same, so we need
-	// to check every possible combination of the input
-	guessCombinations := utils.Permutations(strings.Fields(normalizedGuess))
+	r.touch()
 
-	player := r.Players[playerId]
+	r.mu.Lock()
+	if len(r.PlayedTracks) == 0 {
+		r.mu.Unlock()
+		return nil, ErrRoundNotStarted
+	}
+	currentTrack := r.PlayedTracks[len(r.PlayedTracks)-1]
+	answers := r.answersFor(currentTrack)
+	responseTime := r.roundElapsed()
+	hints := hintFactor(r.hintLevel(responseTime))
+	player, exists := r.Players[playerId]
+	if !exists {
+		r.mu.Unlock()
+		return nil, ErrNotInRoom
+	}
+	if err := r.admitGuess(player, guess); err != nil {
+		result := *player.Guesses[currentTrack.Name]
+		r.mu.Unlock()
+		return &result, err
+	}
+	r.mu.Unlock()
+
+	// The player can guess the artists and the title at the same time, so
+	// every phrase of the guess is matched against each of them
+	phrases := segmentGuess(guess, answers.maxWords)
+	titleScore := r.bestMatch(phrases, answers.title, answers.romanized)
+	artistScores := make(map[string]float32, len(answers.artists))
+	for artist, names := range answers.artists {
+		artistScores[artist] = r.bestMatch(phrases, names, answers.romanized)
+	}
+	targetScores := make(map[string]float32, len(answers.targets)+1)
+	for field, names := range answers.targets {
+		targetScores[field] = r.bestMatch(phrases, names, answers.romanized)
+	}
+	if answers.year > 0 {
+		targetScores[yearField] = yearScore(phrases, answers.year, r.opts.YearTolerance)
+	}
+
+	// The player may have left while the guess was matched
+	r.mu.Lock()
+	if player, exists = r.Players[playerId]; !exists {
+		r.mu.Unlock()
+		return nil, ErrNotInRoom
+	}
 	oldGuessResult := player.Guesses[currentTrack.Name]
 
-	// Match inputs against title and artists
 	newGuessResult := GuessResult{
-		Title:   oldGuessResult.Title,
-		Artists: maps.Clone(oldGuessResult.Artists),
-		score:   oldGuessResult.score,
-	}
-	for _, guess := range guessCombinations {
-		var newGuessScore float32 = 0
-		if newGuessResult.Title != Valid {
-			score := utils.GetScore(guess, normalizedTitle)
-			switch {
-			case score >= float32(r.opts.GuessValidityThreshold):
-				// Add a bonus for the first player to find the title
-				alreadyFound := 0
-				r.mu.Lock()
-				for _, player := range r.Players {
-					if player.Id == player.Id {
-						continue
-					}
-					currentGuess := player.Guesses[currentTrack.Name]
-					if currentGuess.Title == Valid {
-						alreadyFound++
-					}
-				}
-				r.mu.Unlock()
-				switch alreadyFound {
-				case 0:
-					newGuessScore += 50
-				case 1:
-					newGuessScore += 25
-				case 2:
-					newGuessScore += 15
-				}
-				newGuessResult.Title = Valid
-				newGuessScore += 100
-			case score >= float32(r.opts.GuessPartialThreshold):
-				newGuessResult.Title = Partial
-				newGuessScore += score
-			default:
-				newGuessResult.Title = Invalid
-			}
+		Title:        oldGuessResult.Title,
+		Artists:      maps.Clone(oldGuessResult.Artists),
+		Fields:       maps.Clone(oldGuessResult.Fields),
+		score:        oldGuessResult.score,
+		bonus:        oldGuessResult.bonus,
+		ranks:        make(map[string]int, len(oldGuessResult.ranks)+1),
+		points:       make(map[string]float32, len(oldGuessResult.points)+1),
+		penalty:      oldGuessResult.penalty,
+		responseTime: responseTime,
+	}
+	maps.Copy(newGuessResult.ranks, oldGuessResult.ranks)
+	maps.Copy(newGuessResult.points, oldGuessResult.points)
 
-		} else {
-			newGuessScore += 100
-		}
-		for _, artist := range normalizedArtists {
-			if newGuessResult.Artists[artist] != Valid {
-				score := utils.GetScore(guess, artist)
-				switch {
-				case score >= float32(r.opts.GuessValidityThreshold):
-					// Add a bonus for the first player to find the title
-					alreadyFound := 0
-					r.mu.Lock()
-					for _, player := range r.Players {
-						if player.Id == player.Id {
-							continue
-						}
-						currentGuess := player.Guesses[currentTrack.Name]
-						if currentGuess.Title == Valid {
-							alreadyFound++
-						}
-					}
-					r.mu.Unlock()
-					switch alreadyFound {
-					case 0:
-						newGuessScore += 50
-					case 1:
-						newGuessScore += 25
-					case 2:
-						newGuessScore += 15
-					}
-					newGuessResult.Artists[artist] = Valid
-					newGuessScore += 100
-				case score >= float32(r.opts.GuessPartialThreshold):
-					newGuessResult.Artists[artist] = Partial
-					newGuessScore += score
-				default:
-					newGuessResult.Artists[artist] = Invalid
-				}
-			} else {
-				newGuessScore += 100
+	turn := Turn{
+		Elapsed:  responseTime,
+		Duration: r.opts.TrackDuration,
+		Streak:   r.streak(player),
+	}
+
+	// Found fields earn points, plus a bonus for the first players finding
+	// them, and partially found ones points depending on their matching score.
+	// Optional targets earn a share of these, and revealed hints lower them.
+	// Guesses matching nothing may cost points.
+	var newGuessScore float32
+	matched, found := false, false
+	judge := func(field string, previous ResultValidity, score, weight float32) ResultValidity {
+		matched = matched || score >= float32(r.opts.GuessPartialThreshold)
+		switch {
+		case previous == Valid:
+			points, exists := newGuessResult.points[field]
+			if !exists {
+				// Found in a room restored from a snapshot without points
+				points = foundPoints * weight
 			}
+			newGuessScore += points
+			return Valid
+		case score >= float32(r.opts.GuessValidityThreshold):
+			found = true
+			rank, bonus := r.findField(currentTrack.Name, field, player)
+			newGuessResult.ranks[field] = rank
+			newGuessResult.bonus += bonus * weight
+			newGuessResult.points[field] = r.scoring.Points(turn, score, true) * weight * hints
+			newGuessScore += newGuessResult.points[field]
+			return Valid
+		case score >= float32(r.opts.GuessPartialThreshold):
+			newGuessScore += r.scoring.Points(turn, score, false) * weight * hints
+			return Partial
+		default:
+			return previous
 		}
-
-		if newGuessScore > newGuessResult.score {
-			newGuessResult.score = newGuessScore
+	}
+	newGuessResult.Title = judge(titleField, oldGuessResult.Title, titleScore, 1)
+	for artist, score := range artistScores {
+		newGuessResult.Artists[artist] = judge(artistField(artist), oldGuessResult.Artists[artist], score, 1)
+	}
+	for field, score := range targetScores {
+		if newGuessResult.Fields == nil {
+			newGuessResult.Fields = make(map[string]ResultValidity, len(targetScores))
 		}
+		newGuessResult.Fields[field] = judge(field, oldGuessResult.Fields[field], score, targetWeight)
 	}
+	if !matched {
+		newGuessResult.penalty += r.scoring.Penalty(turn)
+	}
+
+	// Partial matches never lower the score of the round, only penalties do
+	earned := max(oldGuessResult.score+oldGuessResult.penalty, newGuessScore+newGuessResult.bonus)
+	newGuessResult.score = earned - newGuessResult.penalty
 
 	player.score += (newGuessResult.score - oldGuessResult.score)
+	player.Guesses[currentTrack.Name] = &newGuessResult
 
 	// Update the score for all players in the room
-	if newGuessResult.score > oldGuessResult.score {
-		scores := make([]struct {
-			Id    string
-			Score float32
-		}, 0, len(r.Players))
-		for _, player := range r.Players {
-			scores = append(scores, struct {
-				Id    string
-				Score float32
-			}{player.Name, player.score})
-		}
-		slices.SortFunc(scores, func(a, b struct {
-			Id    string
-			Score float32
-		},
-		) int {
-			return int(b.Score - a.Score)
-		})
+	var scores, teamScores []Score
+	if newGuessResult.score != oldGuessResult.score {
+		scores, teamScores = r.scores(), r.teamScores()
+	}
 
-		// Send the score to all the players
-		for i := uint8(0); i < r.connectionNumber; i++ {
-			r.Scores <- scores
-		}
+	// Buzzing players answer once, being locked out if they found nothing
+	var resume *RoomEvent
+	if r.opts.Mode == BuzzerMode {
+		resume = r.releaseBuzzer(r.buzzer, playerId, !found)
 	}
+	roundEnd := r.endRoundEarly()
 
-	player.Guesses[currentTrack.Name] = &newGuessResult
-	return &newGuessResult
+	r.mu.Unlock()
+
+	// Send the score to all the players
+	if scores != nil {
+		r.publish(RoomEvent{Kind: ScoresEvent, Scores: scores, TeamScores: teamScores})
+	}
+	if resume != nil {
+		r.publish(*resume)
+	}
+	if roundEnd != nil {
+		r.publish(*roundEnd)
+	}
+
+	return &newGuessResult, nil
+}
+
+// scores returns the scores of the players, best first. It must be called with
+// the room locked.
+func (r *Room) scores() []Score {
+	scores := make([]Score, 0, len(r.Players))
+	for _, player := range r.Players {
+		scores = append(scores, Score{player.Name, player.score})
+	}
+	slices.SortFunc(scores, func(a, b Score) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return scores
 }
 
 func (r *Room) AddPlayer(user *models.User) {
+	if r.remoteOwner != "" {
+		if _, err := r.forward(roomCommand{Action: joinAction, User: *user}); err != nil {
+			log.Errorf("Error forwarding player %s join to room %s: %v", user.ID, r.Id, err)
+		}
+		return
+	}
+	defer r.changed()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	r.connectionNumber += 1
+	r.lastActivity = r.opts.Clock.Now()
+
+	// The game starts with the first connection to the room
+	if !r.started {
+		r.started = true
+		go r.Launch()
+	}
 
 	// The player is already in the room, we just need to update the missing guesses
 	if player, exists := r.Players[user.ID]; exists {
 		log.Infof("Player %s reconnected to room %s", user.ID, r.Id)
 		for _, track := range r.PlayedTracks {
 			if _, exists := player.Guesses[track.Name]; !exists {
-				player.Guesses[track.Name] = &GuessResult{
-					Title:   Invalid,
-					Artists: make(map[string]ResultValidity, len(track.Artists)),
-				}
+				player.Guesses[track.Name] = r.answersFor(track).newResult()
 			}
 		}
 		return
@@ -355,40 +905,71 @@ func (r *Room) AddPlayer(user *models.User) {
 	// Add every guesses for each elapsed tracks
 	guesses := make(map[string]*GuessResult)
 	for _, track := range r.PlayedTracks {
-		guesses[track.Name] = &GuessResult{
-			Title:   Invalid,
-			Artists: make(map[string]ResultValidity, len(track.Artists)),
-		}
+		guesses[track.Name] = r.answersFor(track).newResult()
 	}
 	player.Guesses = guesses
+	r.assignTeam(&player)
+
+	// Players joining an elimination game after its first round only watch it
+	player.Spectator = r.opts.Elimination != NoElimination && len(r.rounds) > 0
 
 	r.Players[user.ID] = &player
+}
 
-	// If the room was empty before, we can start it
-	if len(r.Players) == 1 {
-		go r.Launch()
+// Connect registers a new stream of a player, returning the nonce identifying
+// it.
+func (r *Room) Connect(id string) uint8 {
+	if r.remoteOwner != "" {
+		reply, err := r.forward(roomCommand{Action: connectAction, User: models.User{ID: id}})
+		if err != nil {
+			log.Errorf("Error forwarding player %s connection to room %s: %v", id, r.Id, err)
+		}
+		return reply.Nonce
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	player, exists := r.Players[id]
+	if !exists {
+		return 0
 	}
+	player.Nonce += 1
+	return player.Nonce
 }
 
 func (r *Room) RemovePlayer(id string, nonce uint8) {
-	r.connectionNumber -= 1
+	if r.remoteOwner != "" {
+		if _, err := r.forward(roomCommand{Action: leaveAction, User: models.User{ID: id}, Nonce: nonce}); err != nil {
+			log.Errorf("Error forwarding player %s leave to room %s: %v", id, r.Id, err)
+		}
+		return
+	}
+	defer r.changed()
+
+	r.mu.Lock()
+	if r.connectionNumber > 0 {
+		r.connectionNumber -= 1
+	}
+	r.lastActivity = r.opts.Clock.Now()
 
-	if playerNonce := r.Players[id].Nonce; playerNonce != nonce {
+	if player, exists := r.Players[id]; !exists || player.Nonce != nonce {
+		r.mu.Unlock()
 		return
 	}
 
 	log.Infof("Player %s leaved room %s", id, r.Id)
 
-	r.mu.Lock()
 	delete(r.Players, id)
+	isEmpty := len(r.Players) == 0
 	r.mu.Unlock()
 
 	// The room is empty, remove it
-	if len(r.Players) == 0 {
+	if isEmpty {
 		log.Infof("Room %s is empty, removing it", r.Id)
-		r.ticker.Stop()
-		r.done <- true
-		Mansion.RemoveRoom(r.Id)
-		r = nil
+		r.Close()
+		if r.mansion != nil {
+			r.mansion.RemoveRoom(r.Id)
+		}
 	}
 }
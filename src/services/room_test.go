@@ -0,0 +1,282 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"lcor.io/songs/src/models"
+	"lcor.io/songs/src/utils"
+)
+
+func testPlaylist(size int) models.Playlist {
+	tracks := make([]models.Track, size)
+	for i := range tracks {
+		tracks[i] = models.Track{
+			ID:   fmt.Sprintf("track-%d", i),
+			Name: fmt.Sprintf("Track %d", i),
+			Artists: []models.Artist{
+				{ID: fmt.Sprintf("artist-%d", i), Name: fmt.Sprintf("Artist %d", i)},
+			},
+		}
+	}
+	return models.Playlist{ID: "playlist", Name: "Playlist", Tracks: tracks}
+}
+
+// newTestRoom creates a room, failing the test if its options are refused.
+func newTestRoom(tb testing.TB, playlist models.Playlist, opts ...roomOptFunc) *Room {
+	tb.Helper()
+
+	room, err := NewRoom(playlist, opts...)
+	if err != nil {
+		tb.Fatalf("NewRoom() = %v", err)
+	}
+	return room
+}
+
+// nextTrack waits for the next track event of the room.
+func nextTrack(t *testing.T, events <-chan RoomEvent) models.Track {
+	t.Helper()
+
+	for {
+		select {
+		case event := <-events:
+			if event.Kind == TrackEvent {
+				return event.Track
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no track received")
+		}
+	}
+}
+
+// playGame joins a single player to the room and advances the clock round
+// after round, returning the names of the tracks played in order.
+func playGame(t *testing.T, room *Room, clock *fakeClock) []string {
+	t.Helper()
+
+	events, unsubscribe := room.Events()
+	defer unsubscribe()
+	room.AddPlayer(&models.User{ID: "user", Name: "User"})
+
+	played := make([]string, 0, len(room.Playlist.Tracks))
+	for range room.Playlist.Tracks {
+		played = append(played, nextTrack(t, events).Name)
+		clock.Advance(room.opts.TrackDuration)
+	}
+	return played
+}
+
+func TestRoomPlaysWholePlaylist(t *testing.T) {
+	clock := newFakeClock()
+	room := newTestRoom(t, testPlaylist(10), WithClock(clock), WithSeed(42))
+
+	played := playGame(t, room, clock)
+
+	slices.Sort(played)
+	if got := slices.Compact(played); len(got) != 10 {
+		t.Errorf("played %d distinct tracks; want 10", len(got))
+	}
+}
+
+func TestRoomSeedReproducesTrackOrder(t *testing.T) {
+	firstClock, secondClock := newFakeClock(), newFakeClock()
+	first := newTestRoom(t, testPlaylist(20), WithClock(firstClock), WithSeed(1234))
+	second := newTestRoom(t, testPlaylist(20), WithClock(secondClock), WithSeed(first.Seed()))
+
+	firstOrder := playGame(t, first, firstClock)
+	secondOrder := playGame(t, second, secondClock)
+
+	if !slices.Equal(firstOrder, secondOrder) {
+		t.Errorf("same seed gave different orders:\n%q\n%q", firstOrder, secondOrder)
+	}
+}
+
+func TestRoomDefaultSeedIsRecorded(t *testing.T) {
+	room := newTestRoom(t, testPlaylist(1), WithClock(newFakeClock()))
+
+	if room.Seed() == 0 {
+		t.Error("Seed() = 0; want a seed drawn from the clock")
+	}
+}
+
+func TestRoomSnapshotRestore(t *testing.T) {
+	clock := newFakeClock()
+	room := newTestRoom(t, testPlaylist(5), WithClock(clock), WithSeed(7))
+	events, unsubscribe := room.Events()
+	defer unsubscribe()
+	room.AddPlayer(&models.User{ID: "user", Name: "User"})
+
+	// Play two rounds, find the second track title and leave mid-round
+	nextTrack(t, events)
+	clock.Advance(room.opts.TrackDuration)
+	current := nextTrack(t, events)
+	room.GuessResult("user", current.Name)
+	clock.Advance(10 * time.Second)
+
+	state, err := json.Marshal(room.Snapshot())
+	if err != nil {
+		t.Fatalf("json.Marshal() = %v", err)
+	}
+	room.Close()
+
+	snapshot := RoomSnapshot{}
+	if err := json.Unmarshal(state, &snapshot); err != nil {
+		t.Fatalf("json.Unmarshal() = %v", err)
+	}
+	restored, err := RestoreRoom(snapshot, WithClock(clock))
+	if err != nil {
+		t.Fatalf("RestoreRoom() = %v", err)
+	}
+
+	if restored.Id != room.Id || restored.Seed() != room.Seed() {
+		t.Errorf("restored room %s (seed %d); want %s (seed %d)", restored.Id, restored.Seed(), room.Id, room.Seed())
+	}
+	player := restored.Players["user"]
+	if player == nil || player.score != room.Players["user"].score {
+		t.Fatalf("restored player %+v; want score %v", player, room.Players["user"].score)
+	}
+	if player.Guesses[current.Name].Title != Valid || player.Guesses[current.Name].TitleRank() != 1 {
+		t.Errorf("restored guess title = %v; want %v, found first", player.Guesses[current.Name].Title, Valid)
+	}
+	if finders := restored.TitleFinders(current.Name); len(finders) != 1 || finders[0].PlayerId != "user" {
+		t.Errorf("restored title finders = %v; want user", finders)
+	}
+
+	// The player reconnects long after the restart, resuming on the current
+	// track, then the round ends after the time it had left
+	clock.Advance(time.Minute)
+	if resnapshot := restored.Snapshot(); resnapshot.Elapsed != 10*time.Second {
+		t.Errorf("elapsed before resuming = %v; want 10s", resnapshot.Elapsed)
+	}
+	restoredEvents, unsubscribeRestored := restored.Events()
+	defer unsubscribeRestored()
+	restored.AddPlayer(&models.User{ID: "user", Name: "User"})
+	if played := restored.Played(); played[len(played)-1].Name != current.Name {
+		t.Errorf("resumed on %q; want %q", played[len(played)-1].Name, current.Name)
+	}
+	clock.WaitForTickers(t, 1)
+	restored.mu.Lock()
+	ticker := restored.ticker.(*fakeTicker)
+	restored.mu.Unlock()
+	clock.mu.Lock()
+	end := ticker.next
+	clock.mu.Unlock()
+	if want := clock.Now().Add(restored.opts.TrackDuration - 10*time.Second); !end.Equal(want) {
+		t.Errorf("resumed round ends at %v; want %v", end, want)
+	}
+	clock.Advance(restored.opts.TrackDuration - 10*time.Second)
+	next := nextTrack(t, restoredEvents)
+	if slices.ContainsFunc(snapshot.PlayedTracks, func(t models.Track) bool { return t.Name == next.Name }) {
+		t.Errorf("resumed room replayed %q", next.Name)
+	}
+	restored.Close()
+}
+
+func TestRoomMatcherOption(t *testing.T) {
+	room := newTestRoom(t, testPlaylist(1), WithMatcher(utils.TokenSetMatcher))
+	if room.matcher != (utils.TokenSet{}) {
+		t.Errorf("room matcher = %T; want utils.TokenSet", room.matcher)
+	}
+
+	restored, err := RestoreRoom(room.Snapshot())
+	if err != nil {
+		t.Fatalf("RestoreRoom() = %v", err)
+	}
+	if restored.matcher != (utils.TokenSet{}) {
+		t.Errorf("restored room matcher = %T; want utils.TokenSet", restored.matcher)
+	}
+
+	unknown := newTestRoom(t, testPlaylist(1), WithMatcher("unknown"))
+	if unknown.matcher != (utils.Levenshtein{}) {
+		t.Errorf("unknown matcher = %T; want fallback to utils.Levenshtein", unknown.matcher)
+	}
+}
+
+func TestRoomGuessBeforeFirstRound(t *testing.T) {
+	for _, mode := range []GameMode{TextMode, ChoiceMode, YearMode} {
+		room := newTestRoom(t, testPlaylist(3), WithClock(newFakeClock()), WithMode(mode))
+		room.Players["user"] = &Player{Id: "user", PlayerId: "user", Name: "User", Guesses: map[string]*GuessResult{}}
+
+		if result, err := room.GuessResult("user", "track 0"); result != nil || err != ErrRoundNotStarted {
+			t.Errorf("%s guess = %+v, %v; want %v", mode, result, err, ErrRoundNotStarted)
+		}
+		if result, err := room.Choose("user", "choice"); result != nil || err != ErrRoundNotStarted {
+			t.Errorf("%s choice = %+v, %v; want %v", mode, result, err, ErrRoundNotStarted)
+		}
+	}
+}
+
+func TestRoomGuessAcceptsAliases(t *testing.T) {
+	clock := newFakeClock()
+	playlist := models.Playlist{ID: "playlist", Name: "Playlist", Tracks: []models.Track{{
+		ID:      "track",
+		Name:    "Highway to Hell",
+		Artists: []models.Artist{{ID: "artist", Name: "AC/DC"}},
+	}}}
+	room := newTestRoom(t, playlist, WithClock(clock))
+
+	events, unsubscribe := room.Events()
+	defer unsubscribe()
+	room.AddPlayer(&models.User{ID: "user", Name: "User"})
+	nextTrack(t, events)
+
+	result, _ := room.GuessResult("user", "acdc")
+	if result.Artists["ac dc"] != Valid {
+		t.Errorf("alias guess result = %v; want a valid artist", result.Artists)
+	}
+}
+
+func TestRoomGuessIgnoresArticlesAndVersions(t *testing.T) {
+	clock := newFakeClock()
+	playlist := models.Playlist{ID: "playlist", Name: "Playlist", Tracks: []models.Track{{
+		ID:      "track",
+		Name:    "Let It Be - Remastered 2009",
+		Artists: []models.Artist{{ID: "artist", Name: "The Beatles"}},
+	}}}
+	room := newTestRoom(t, playlist, WithClock(clock))
+
+	events, unsubscribe := room.Events()
+	defer unsubscribe()
+	room.AddPlayer(&models.User{ID: "user", Name: "User"})
+	nextTrack(t, events)
+
+	result, _ := room.GuessResult("user", "let it be the beatles")
+	if result.Title != Valid || result.Artists["beatles"] != Valid {
+		t.Errorf("guess result = %v, %v; want a valid title and artist", result.Title, result.Artists)
+	}
+}
+
+func TestRoomGuessAcceptsOtherScripts(t *testing.T) {
+	clock := newFakeClock()
+	playlist := models.Playlist{ID: "playlist", Name: "Playlist", Tracks: []models.Track{{
+		ID:      "track",
+		Name:    "Группа крови",
+		Artists: []models.Artist{{ID: "artist", Name: "Кино"}},
+	}}}
+	room := newTestRoom(t, playlist, WithClock(clock))
+
+	events, unsubscribe := room.Events()
+	defer unsubscribe()
+	room.AddPlayer(&models.User{ID: "user", Name: "User"})
+	nextTrack(t, events)
+
+	if result, _ := room.GuessResult("user", "gruppa krovi"); result.Title != Valid {
+		t.Errorf("romanized title guess = %v; want valid", result.Title)
+	}
+	if result, _ := room.GuessResult("user", "Кино"); result.Artists["кино"] != Valid {
+		t.Errorf("original artist guess = %v; want valid", result.Artists)
+	}
+}
+
+func TestRoomGuessAcceptsRomanizedSpellings(t *testing.T) {
+	track := models.Track{ID: "track", Name: "강남스타일", Artists: []models.Artist{{ID: "artist", Name: "싸이"}}}
+	room := startRound(t, track, 1)
+	defer room.Close()
+
+	if result, _ := room.GuessResult("user-0", "gangnam style"); result.Title != Valid {
+		t.Errorf("romanized title guess = %v; want valid without an alias", result.Title)
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v3/log"
+
+	"lcor.io/songs/src/models"
+	"lcor.io/songs/src/utils"
+)
+
+// RoundType is what players get to guess a track from.
+type RoundType string
+
+const (
+	AudioRound  RoundType = "audio"  // The preview of the track
+	CoverRound  RoundType = "cover"  // The album cover, blurred then clearer
+	LyricsRound RoundType = "lyrics" // A few lines of the lyrics
+)
+
+// Valid reports whether the round type is known.
+func (t RoundType) Valid() bool {
+	switch t {
+	case AudioRound, CoverRound, LyricsRound:
+		return true
+	}
+	return false
+}
+
+// Lines of lyrics shown in a lyrics round
+const snippetLines = 2
+
+// Round is what players get to guess the current track from.
+type Round struct {
+	Type   RoundType
+	Cover  string   // Url of the album cover, in cover rounds
+	Lyrics []string // Snippet of the lyrics, in lyrics rounds
+}
+
+// Audible reports whether players hear the preview of the track in the round,
+// rooms restored without their round playing audio ones.
+func (r Round) Audible() bool {
+	return r.Type != CoverRound && r.Type != LyricsRound
+}
+
+// newRound draws the type of the round of the track among the room ones,
+// falling back to a cover or an audio round when the track lacks what it
+// needs. It is only called by the game loop, which owns the random source.
+func (r *Room) newRound(track models.Track) Round {
+	roundType := AudioRound
+	switch len(r.opts.RoundTypes) {
+	case 0:
+	case 1:
+		roundType = r.opts.RoundTypes[0]
+	default:
+		roundType = r.opts.RoundTypes[r.rng.Intn(len(r.opts.RoundTypes))]
+	}
+
+	if roundType == LyricsRound {
+		if snippet := r.lyricsSnippet(track); len(snippet) > 0 {
+			return Round{Type: LyricsRound, Lyrics: snippet}
+		}
+		roundType = CoverRound
+	}
+	if roundType == CoverRound && track.Image.Url != "" {
+		return Round{Type: CoverRound, Cover: track.Image.Url}
+	}
+	return Round{Type: AudioRound}
+}
+
+// lyricsSnippet draws a few consecutive lines of the lyrics of the track which
+// do not give its title away.
+func (r *Room) lyricsSnippet(track models.Track) []string {
+	if r.opts.Lyrics == nil {
+		return nil
+	}
+	lines, err := r.opts.Lyrics.Lyrics(track)
+	if err != nil {
+		if !errors.Is(err, ErrNoLyrics) {
+			log.Errorf("%v", err)
+		}
+		return nil
+	}
+
+	title := utils.Normalize(track.Name)
+	revealing := func(line string) bool {
+		return strings.Contains(utils.Normalize(line), title)
+	}
+	candidates := make([]int, 0, len(lines))
+	for i, line := range lines {
+		if !revealing(line) {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	start := candidates[r.rng.Intn(len(candidates))]
+	snippet := make([]string, 0, snippetLines)
+	for _, line := range lines[start:] {
+		if len(snippet) == snippetLines || revealing(line) {
+			break
+		}
+		snippet = append(snippet, line)
+	}
+	return snippet
+}
+
+// Round returns what players get to guess the current track from.
+func (r *Room) Round() Round {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	round := r.round
+	round.Lyrics = slices.Clone(round.Lyrics)
+	return round
+}
+
+// validRoundTypes drops the unknown round types.
+func validRoundTypes(types []RoundType) []RoundType {
+	valid := make([]RoundType, 0, len(types))
+	for _, roundType := range types {
+		if !roundType.Valid() {
+			log.Warnf("Unknown round type %q, ignoring it", roundType)
+			continue
+		}
+		valid = append(valid, roundType)
+	}
+	return valid
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"lcor.io/songs/src/models"
+)
+
+// mapLyrics serves the lyrics of tracks by id.
+type mapLyrics map[string][]string
+
+func (m mapLyrics) Lyrics(track models.Track) ([]string, error) {
+	if lines, exists := m[track.ID]; exists {
+		return lines, nil
+	}
+	return nil, ErrNoLyrics
+}
+
+func TestFileLyrics(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "track-0.txt"), []byte("First line\n\n  Second line \n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	provider := FileLyrics{Dir: dir}
+
+	lines, err := provider.Lyrics(models.Track{ID: "track-0"})
+	if err != nil || !slices.Equal(lines, []string{"First line", "Second line"}) {
+		t.Errorf("lyrics = %q, %v; want the two lines", lines, err)
+	}
+	for _, id := range []string{"track-1", "", "../track-0"} {
+		if _, err := provider.Lyrics(models.Track{ID: id}); err != ErrNoLyrics {
+			t.Errorf("lyrics of %q error = %v; want %v", id, err, ErrNoLyrics)
+		}
+	}
+}
+
+func TestLyricsSnippet(t *testing.T) {
+	track := models.Track{ID: "track", Name: "Under Pressure"}
+	lyrics := mapLyrics{"track": {"Pressure pushing down on me", "Under pressure", "That burns a building down", "Splits a family in two", "Under pressure"}}
+	room := newTestRoom(t, testPlaylist(1), WithLyrics(lyrics))
+
+	// Lines with the title are never shown
+	for i := 0; i < 20; i++ {
+		snippet := room.lyricsSnippet(track)
+		if len(snippet) == 0 || len(snippet) > snippetLines {
+			t.Fatalf("snippet %q; want 1 to %d lines", snippet, snippetLines)
+		}
+		for _, line := range snippet {
+			if strings.Contains(strings.ToLower(line), "under pressure") {
+				t.Errorf("snippet %q gives the title away", snippet)
+			}
+		}
+	}
+}
+
+func TestNewRound(t *testing.T) {
+	cover := models.Image{Url: "https://example.com/cover.jpg"}
+	lyrics := mapLyrics{"sung": {"Some line", "Another line"}}
+
+	testcases := []struct {
+		name  string
+		types []RoundType
+		track models.Track
+		want  RoundType
+	}{
+		{"default", nil, models.Track{ID: "sung", Name: "Song", Image: cover}, AudioRound},
+		{"lyrics", []RoundType{LyricsRound}, models.Track{ID: "sung", Name: "Song", Image: cover}, LyricsRound},
+		{"lyrics without lyrics", []RoundType{LyricsRound}, models.Track{ID: "other", Name: "Song", Image: cover}, CoverRound},
+		{"cover", []RoundType{CoverRound, "unknown"}, models.Track{ID: "sung", Name: "Song", Image: cover}, CoverRound},
+		{"cover without cover", []RoundType{CoverRound}, models.Track{ID: "sung", Name: "Song"}, AudioRound},
+	}
+
+	for _, tc := range testcases {
+		room := newTestRoom(t, testPlaylist(1), WithRoundTypes(tc.types...), WithLyrics(lyrics))
+		round := room.newRound(tc.track)
+		if round.Type != tc.want {
+			t.Errorf("%s: round %+v; want %s", tc.name, round, tc.want)
+		}
+		if round.Audible() != (tc.want == AudioRound) {
+			t.Errorf("%s: audible %v; want %v", tc.name, round.Audible(), tc.want == AudioRound)
+		}
+	}
+
+	// Mixed rounds draw their type from the room seed
+	room := newTestRoom(t, testPlaylist(1), WithSeed(1), WithRoundTypes(AudioRound, CoverRound), WithLyrics(lyrics))
+	types := map[RoundType]bool{}
+	for i := 0; i < 20; i++ {
+		types[room.newRound(models.Track{ID: "sung", Name: "Song", Image: cover}).Type] = true
+	}
+	if !types[AudioRound] || !types[CoverRound] {
+		t.Errorf("round types %v; want both audio and cover rounds", types)
+	}
+}
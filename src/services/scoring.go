@@ -0,0 +1,238 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ScoreDecay is the curve along which the points of a found field decrease
+// over a round.
+type ScoreDecay string
+
+const (
+	NoDecay          ScoreDecay = ""
+	LinearDecay      ScoreDecay = "linear"
+	ExponentialDecay ScoreDecay = "exponential"
+	SteppedDecay     ScoreDecay = "stepped"
+)
+
+const (
+	// Share of the points still earned at the end of a round, so a late answer
+	// is always worth more than a wrong one
+	minDecayFactor = 0.1
+	// Rate of the exponential decay, leaving about 22% of the points halfway
+	exponentialDecayRate = 3
+	// Number of steps of the stepped decay
+	decaySteps = 4
+)
+
+// Valid reports whether the decay curve is known.
+func (d ScoreDecay) Valid() bool {
+	switch d {
+	case NoDecay, LinearDecay, ExponentialDecay, SteppedDecay:
+		return true
+	}
+	return false
+}
+
+// factor returns the share of the points earned by a guess arriving elapsed
+// after the start of a round lasting duration.
+func (d ScoreDecay) factor(elapsed, duration time.Duration) float32 {
+	if d == NoDecay || duration <= 0 {
+		return 1
+	}
+	progress := min(max(elapsed.Seconds()/duration.Seconds(), 0), 1)
+
+	var factor float64
+	switch d {
+	case LinearDecay:
+		factor = 1 - progress
+	case ExponentialDecay:
+		factor = math.Exp(-exponentialDecayRate * progress)
+	case SteppedDecay:
+		factor = 1 - math.Floor(progress*decaySteps)/decaySteps
+	default:
+		return 1
+	}
+	return float32(max(factor, minDecayFactor))
+}
+
+// Turn describes a guess being scored.
+type Turn struct {
+	Elapsed  time.Duration // Time between the start of the round and the guess
+	Duration time.Duration // Duration of the round
+	Streak   int           // Previous consecutive rounds in which the player found a field
+}
+
+// streak returns the number of consecutive rounds before the current one in
+// which the player found a field. It must be called with the room locked.
+func (r *Room) streak(player *Player) int {
+	streak := 0
+	for i := len(r.PlayedTracks) - 2; i >= 0 && streak < maxStreak; i-- {
+		guess, exists := player.Guesses[r.PlayedTracks[i].Name]
+		if !exists || !guess.foundAny() {
+			break
+		}
+		streak++
+	}
+	return streak
+}
+
+// ScoringStrategy decides the points earned and lost by the guesses of the
+// players.
+type ScoringStrategy interface {
+	// Points returns the points earned by a field found, or partially found
+	// with its matching score, by the guess.
+	Points(turn Turn, score float32, found bool) float32
+	// Penalty returns the points lost by a guess matching nothing.
+	Penalty(turn Turn) float32
+}
+
+// Names of the available scoring strategies
+const (
+	ClassicScoring = "classic"
+	SpeedScoring   = "speed"
+	StreakScoring  = "streak"
+	PenaltyScoring = "penalty"
+)
+
+const (
+	// Points earned by a found field
+	foundPoints = 100
+	// Multiplier gained by each consecutive round in which a field was found,
+	// up to maxStreak rounds
+	streakStep = 0.25
+	maxStreak  = 4
+	// Points lost by a wrong guess with penalty scoring, unless the room sets
+	// its own
+	defaultWrongGuessPenalty = 10
+)
+
+var ErrUnknownScoring = errors.New("Unknown scoring strategy")
+
+// NewScoring builds the scoring strategy of the room options. Without a
+// strategy, it falls back to the classic one, or to the speed one in
+// multiple-choice rooms. A score decay decreases the points of any strategy
+// over the round, and a wrong guess penalty takes points away from the wrong
+// guesses of any strategy.
+func NewScoring(opts RoomOpts) (ScoringStrategy, error) {
+	name := opts.Scoring
+	if name == "" {
+		name = ClassicScoring
+		// Multiple-choice rounds reward fast answers, the right one being
+		// handed to the players
+		if opts.Mode == ChoiceMode {
+			name = SpeedScoring
+		}
+	}
+
+	var strategy ScoringStrategy
+	switch name {
+	case ClassicScoring:
+		strategy = Classic{}
+	case SpeedScoring:
+		// Speed scoring always decays, linearly unless set otherwise
+		decay := opts.ScoreDecay
+		if decay == NoDecay {
+			decay = LinearDecay
+		}
+		strategy = Speed{Decay: decay}
+	case StreakScoring:
+		strategy = Streak{Step: streakStep, MaxStreak: maxStreak}
+	case PenaltyScoring:
+		cost := opts.WrongGuessPenalty
+		if cost == 0 {
+			cost = defaultWrongGuessPenalty
+		}
+		strategy = Penalty{Cost: cost}
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownScoring, opts.Scoring)
+	}
+
+	if opts.ScoreDecay != NoDecay && name != SpeedScoring {
+		strategy = decayed{ScoringStrategy: strategy, Decay: opts.ScoreDecay}
+	}
+	if opts.WrongGuessPenalty > 0 && name != PenaltyScoring {
+		strategy = penalized{ScoringStrategy: strategy, Cost: opts.WrongGuessPenalty}
+	}
+	return strategy, nil
+}
+
+// decayed decreases the points of a strategy over the round.
+type decayed struct {
+	ScoringStrategy
+	Decay ScoreDecay
+}
+
+func (d decayed) Points(turn Turn, score float32, found bool) float32 {
+	return d.ScoringStrategy.Points(turn, score, found) * d.Decay.factor(turn.Elapsed, turn.Duration)
+}
+
+// penalized takes points away from the wrong guesses of a strategy.
+type penalized struct {
+	ScoringStrategy
+	Cost float32
+}
+
+func (p penalized) Penalty(turn Turn) float32 {
+	return p.Cost
+}
+
+// Classic awards 100 points by found field and the matching score of the
+// partially found ones.
+type Classic struct{}
+
+func (Classic) Points(turn Turn, score float32, found bool) float32 {
+	if found {
+		return foundPoints
+	}
+	return score
+}
+
+func (Classic) Penalty(turn Turn) float32 {
+	return 0
+}
+
+// Speed decreases the classic points over the round.
+type Speed struct {
+	Decay ScoreDecay
+}
+
+func (s Speed) Points(turn Turn, score float32, found bool) float32 {
+	return Classic{}.Points(turn, score, found) * s.Decay.factor(turn.Elapsed, turn.Duration)
+}
+
+func (Speed) Penalty(turn Turn) float32 {
+	return 0
+}
+
+// Streak multiplies the classic points of players finding fields round after
+// round.
+type Streak struct {
+	Step      float32
+	MaxStreak int
+}
+
+func (s Streak) Points(turn Turn, score float32, found bool) float32 {
+	return Classic{}.Points(turn, score, found) * (1 + s.Step*float32(min(turn.Streak, s.MaxStreak)))
+}
+
+func (Streak) Penalty(turn Turn) float32 {
+	return 0
+}
+
+// Penalty takes points away from wrong guesses, discouraging players from
+// trying every name they know.
+type Penalty struct {
+	Cost float32
+}
+
+func (Penalty) Points(turn Turn, score float32, found bool) float32 {
+	return Classic{}.Points(turn, score, found)
+}
+
+func (p Penalty) Penalty(turn Turn) float32 {
+	return p.Cost
+}
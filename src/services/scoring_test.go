@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"lcor.io/songs/src/models"
+)
+
+func TestScoreDecayFactor(t *testing.T) {
+	testcases := []struct {
+		decay   ScoreDecay
+		elapsed time.Duration
+		want    float32
+	}{
+		{NoDecay, 20 * time.Second, 1},
+		{LinearDecay, 0, 1},
+		{LinearDecay, 15 * time.Second, 0.5},
+		{LinearDecay, 30 * time.Second, minDecayFactor},
+		{LinearDecay, time.Minute, minDecayFactor},
+		{ExponentialDecay, 0, 1},
+		{ExponentialDecay, 10 * time.Second, 0.368},
+		{ExponentialDecay, 30 * time.Second, minDecayFactor},
+		{SteppedDecay, 7 * time.Second, 1},
+		{SteppedDecay, 8 * time.Second, 0.75},
+		{SteppedDecay, 29 * time.Second, 0.25},
+	}
+
+	for _, tc := range testcases {
+		factor := tc.decay.factor(tc.elapsed, 30*time.Second)
+		if math.Abs(float64(factor-tc.want)) > 0.001 {
+			t.Errorf("%q.factor(%v) = %.3f; want %.3f", tc.decay, tc.elapsed, factor, tc.want)
+		}
+	}
+}
+
+func TestGuessScoreDecay(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 1, WithScoreDecay(LinearDecay), WithFinderBonuses())
+	clock := room.opts.Clock.(*fakeClock)
+
+	clock.Advance(6 * time.Second)
+	result, _ := room.GuessResult("user-0", "dont stop me now")
+	if result.score != 80 || result.ResponseTime() != 6*time.Second {
+		t.Errorf("score = %v after %v; want 80 after 6s", result.score, result.ResponseTime())
+	}
+
+	// The title keeps the points it earned when found
+	clock.Advance(9 * time.Second)
+	result, _ = room.GuessResult("user-0", "queen")
+	if result.score != 130 || result.ResponseTime() != 15*time.Second {
+		t.Errorf("score = %v after %v; want 130 after 15s", result.score, result.ResponseTime())
+	}
+}
+
+func TestRoomUnknownScoreDecay(t *testing.T) {
+	room := newTestRoom(t, testPlaylist(1), WithScoreDecay("quadratic"))
+	if room.opts.ScoreDecay != NoDecay {
+		t.Errorf("score decay = %q; want flat scores", room.opts.ScoreDecay)
+	}
+}
+
+func TestRoomUnknownScoring(t *testing.T) {
+	if _, err := NewRoom(testPlaylist(1), WithScoring("unknown")); !errors.Is(err, ErrUnknownScoring) {
+		t.Errorf("NewRoom(unknown scoring) = %v; want %v", err, ErrUnknownScoring)
+	}
+}
+
+var scriptPlaylist = models.Playlist{ID: "playlist", Name: "Playlist", Tracks: []models.Track{
+	{ID: "bohemian", Name: "Bohemian Rhapsody", Artists: []models.Artist{{ID: "queen", Name: "Queen"}}},
+	{ID: "teen", Name: "Smells Like Teen Spirit", Artists: []models.Artist{{ID: "nirvana", Name: "Nirvana"}}},
+	{ID: "jean", Name: "Billie Jean", Artists: []models.Artist{{ID: "jackson", Name: "Michael Jackson"}}},
+}}
+
+// playScript plays a scripted game with two players, without finder bonuses,
+// and returns their final scores. Each step either waits ("wait 10s"), moves
+// to the next round ("next") or sends a player guess of the title, the artist,
+// both or a wrong answer ("user-0 title").
+func playScript(t *testing.T, script []string, opts ...roomOptFunc) map[string]float32 {
+	t.Helper()
+
+	clock := newFakeClock()
+	room := newTestRoom(t, scriptPlaylist, append([]roomOptFunc{WithClock(clock), WithFinderBonuses()}, opts...)...)
+	defer room.Close()
+	events, unsubscribe := room.Events()
+	defer unsubscribe()
+	room.AddPlayer(&models.User{ID: "user-0", Name: "User 0"})
+	room.AddPlayer(&models.User{ID: "user-1", Name: "User 1"})
+
+	track := nextTrack(t, events)
+	var elapsed time.Duration
+	for _, step := range script {
+		fields := strings.Fields(step)
+		switch fields[0] {
+		case "wait":
+			d, err := time.ParseDuration(fields[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			clock.Advance(d)
+			elapsed += d
+		case "next":
+			clock.Advance(room.opts.TrackDuration - elapsed)
+			elapsed = 0
+			track = nextTrack(t, events)
+		default:
+			guesses := map[string]string{
+				"title":  track.Name,
+				"artist": track.Artists[0].Name,
+				"both":   track.Name + " " + track.Artists[0].Name,
+				"wrong":  "zzz",
+			}
+			room.GuessResult(fields[0], guesses[fields[1]])
+		}
+	}
+
+	scores := map[string]float32{}
+	for id, player := range room.Players {
+		scores[id] = player.score
+	}
+	return scores
+}
+
+func TestScoringStrategies(t *testing.T) {
+	testcases := []struct {
+		scoring string
+		script  []string
+		want    [2]float32
+	}{
+		{
+			ClassicScoring,
+			[]string{"user-0 title", "user-0 artist", "user-1 wrong", "next", "user-1 both"},
+			[2]float32{200, 200},
+		},
+		{
+			SpeedScoring,
+			[]string{"wait 15s", "user-0 title", "wait 3s", "user-1 title", "next", "user-1 artist"},
+			[2]float32{50, 140},
+		},
+		{
+			StreakScoring,
+			[]string{"user-0 title", "next", "user-0 title", "user-1 title", "next", "user-0 artist", "user-1 title"},
+			[2]float32{375, 225},
+		},
+		{
+			PenaltyScoring,
+			[]string{"user-0 wrong", "user-0 wrong", "user-0 title", "user-0 title", "user-1 wrong"},
+			[2]float32{80, -10},
+		},
+	}
+
+	for _, tc := range testcases {
+		scores := playScript(t, tc.script, WithScoring(tc.scoring))
+		for i, want := range tc.want {
+			id := fmt.Sprintf("user-%d", i)
+			if math.Abs(float64(scores[id]-want)) > 0.01 {
+				t.Errorf("%s: %s scored %v; want %v", tc.scoring, id, scores[id], want)
+			}
+		}
+	}
+}
+
+func TestNewScoring(t *testing.T) {
+	if s, err := NewScoring(RoomOpts{}); err != nil || s != (Classic{}) {
+		t.Errorf("NewScoring() = %v, %v; want classic", s, err)
+	}
+	if s, _ := NewScoring(RoomOpts{Scoring: SpeedScoring}); s != (Speed{Decay: LinearDecay}) {
+		t.Errorf("NewScoring(speed) = %v; want linear decay", s)
+	}
+	if _, err := NewScoring(RoomOpts{Scoring: "unknown"}); !errors.Is(err, ErrUnknownScoring) {
+		t.Error("NewScoring should reject unknown strategies")
+	}
+
+	// The decay applies on top of the chosen strategy
+	turn := Turn{Elapsed: 15 * time.Second, Duration: 30 * time.Second, Streak: 2}
+	s, err := NewScoring(RoomOpts{Scoring: StreakScoring, ScoreDecay: LinearDecay})
+	if err != nil {
+		t.Fatalf("NewScoring(streak, linear decay) = %v", err)
+	}
+	if points := s.Points(turn, 0, true); points != 75 {
+		t.Errorf("decayed streak points = %v; want 75", points)
+	}
+	if s, _ := NewScoring(RoomOpts{ScoreDecay: SteppedDecay}); s.Points(turn, 0, true) != 50 {
+		t.Errorf("decayed classic points = %v; want 50", s.Points(turn, 0, true))
+	}
+
+	// So does the wrong guess penalty, penalty scoring costing 10 points
+	// unless set otherwise
+	if s, _ := NewScoring(RoomOpts{Scoring: PenaltyScoring}); s.Penalty(turn) != defaultWrongGuessPenalty {
+		t.Errorf("penalty scoring penalty = %v; want %v", s.Penalty(turn), defaultWrongGuessPenalty)
+	}
+	s, err = NewScoring(RoomOpts{Scoring: SpeedScoring, WrongGuessPenalty: 5, ScoreDecay: SteppedDecay})
+	if err != nil {
+		t.Fatalf("NewScoring(speed, penalty 5) = %v", err)
+	}
+	if points, penalty := s.Points(turn, 0, true), s.Penalty(turn); points != 50 || penalty != 5 {
+		t.Errorf("penalized speed points %v, penalty %v; want 50, 5", points, penalty)
+	}
+}
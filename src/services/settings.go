@@ -0,0 +1,75 @@
+package services
+
+// RoomSettings are the options chosen by the creator of a room. Settings left
+// empty keep the room defaults.
+type RoomSettings struct {
+	Mode              GameMode
+	Matcher           string
+	Scoring           string
+	ScoreDecay        ScoreDecay
+	WrongGuessPenalty float32
+	GuessRate         float32 // Guesses per second
+	MaxAttempts       int8    // Guesses per round
+	Hints             int     // Revealed evenly over each round
+	SkipMajority      float32
+	FirstFinders      int
+	Teams             []string
+	RoundTypes        []RoundType
+	Targets           []Target
+	Elimination       EliminationRule
+	ChronologyRound   bool
+}
+
+// Options returns the room options applying the settings.
+func (s RoomSettings) Options() []roomOptFunc {
+	opts := make([]roomOptFunc, 0)
+	if s.Mode != "" {
+		opts = append(opts, WithMode(s.Mode))
+	}
+	if s.Matcher != "" {
+		opts = append(opts, WithMatcher(s.Matcher))
+	}
+	if s.Scoring != "" {
+		opts = append(opts, WithScoring(s.Scoring))
+	}
+	if s.ScoreDecay != NoDecay {
+		opts = append(opts, WithScoreDecay(s.ScoreDecay))
+	}
+	if s.WrongGuessPenalty > 0 {
+		opts = append(opts, WithWrongGuessPenalty(s.WrongGuessPenalty))
+	}
+	if s.GuessRate > 0 {
+		// Keep the default burst of guesses
+		opts = append(opts, func(o *RoomOpts) {
+			o.GuessRate = s.GuessRate
+		})
+	}
+	if s.MaxAttempts > 0 {
+		opts = append(opts, WithMaxAttempts(s.MaxAttempts))
+	}
+	if s.Hints > 0 {
+		opts = append(opts, WithEvenHints(min(s.Hints, maxHintLevel)))
+	}
+	if s.SkipMajority > 0 {
+		opts = append(opts, WithSkipMajority(s.SkipMajority))
+	}
+	if s.FirstFinders > 0 {
+		opts = append(opts, WithFirstFinders(s.FirstFinders))
+	}
+	if len(s.Teams) > 0 {
+		opts = append(opts, WithTeams(s.Teams...))
+	}
+	if len(s.RoundTypes) > 0 {
+		opts = append(opts, WithRoundTypes(s.RoundTypes...))
+	}
+	if len(s.Targets) > 0 {
+		opts = append(opts, WithTargets(s.Targets...))
+	}
+	if s.Elimination != NoElimination {
+		opts = append(opts, WithElimination(s.Elimination))
+	}
+	if s.ChronologyRound {
+		opts = append(opts, WithChronologyRound())
+	}
+	return opts
+}
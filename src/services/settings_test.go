@@ -0,0 +1,71 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"lcor.io/songs/src/utils"
+)
+
+func TestRoomSettings(t *testing.T) {
+	if opts := (RoomSettings{}).Options(); len(opts) != 0 {
+		t.Errorf("empty settings gave %d options; want the defaults", len(opts))
+	}
+
+	testcases := []struct {
+		name     string
+		settings RoomSettings
+		applied  func(o RoomOpts) bool
+	}{
+		{"matcher", RoomSettings{Matcher: utils.PhoneticMatcher}, func(o RoomOpts) bool {
+			return len(o.Matchers) == 1 && o.Matchers[0].Name == utils.PhoneticMatcher
+		}},
+		{"score decay", RoomSettings{ScoreDecay: ExponentialDecay}, func(o RoomOpts) bool {
+			return o.ScoreDecay == ExponentialDecay
+		}},
+		{"scoring", RoomSettings{Scoring: StreakScoring}, func(o RoomOpts) bool {
+			return o.Scoring == StreakScoring
+		}},
+		{"wrong guess penalty", RoomSettings{WrongGuessPenalty: 5}, func(o RoomOpts) bool {
+			return o.WrongGuessPenalty == 5
+		}},
+		{"guess limits", RoomSettings{GuessRate: 2, MaxAttempts: 3}, func(o RoomOpts) bool {
+			return o.GuessRate == 2 && o.GuessBurst == defaultOpts().GuessBurst && o.MaxAttempts == 3
+		}},
+		{"targets", RoomSettings{Targets: []Target{AlbumTarget}}, func(o RoomOpts) bool {
+			return slices.Equal(o.Targets, []Target{AlbumTarget})
+		}},
+		{"mode", RoomSettings{Mode: ChoiceMode}, func(o RoomOpts) bool {
+			return o.Mode == ChoiceMode
+		}},
+		{"teams", RoomSettings{Teams: []string{"Red", "Blue"}}, func(o RoomOpts) bool {
+			return slices.Equal(o.Teams, []string{"Red", "Blue"})
+		}},
+		{"elimination", RoomSettings{Elimination: LowestScoreElimination}, func(o RoomOpts) bool {
+			return o.Elimination == LowestScoreElimination
+		}},
+		{"hints capped to the hint levels", RoomSettings{Hints: 5}, func(o RoomOpts) bool {
+			return slices.Equal(o.HintDelays, []time.Duration{7500 * time.Millisecond, 15 * time.Second, 22500 * time.Millisecond})
+		}},
+		{"skip majority", RoomSettings{SkipMajority: 1}, func(o RoomOpts) bool {
+			return o.SkipMajority == 1
+		}},
+		{"first finders", RoomSettings{FirstFinders: 1}, func(o RoomOpts) bool {
+			return o.FirstFinders == 1
+		}},
+		{"round types", RoomSettings{RoundTypes: []RoundType{CoverRound}}, func(o RoomOpts) bool {
+			return slices.Equal(o.RoundTypes, []RoundType{CoverRound})
+		}},
+		{"chronology round", RoomSettings{ChronologyRound: true}, func(o RoomOpts) bool {
+			return o.ChronologyRound
+		}},
+	}
+
+	for _, tc := range testcases {
+		opts := newTestRoom(t, testPlaylist(1), tc.settings.Options()...).Opts()
+		if !tc.applied(opts) {
+			t.Errorf("%s: options %+v; want %+v applied", tc.name, opts, tc.settings)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3/log"
+
+	"lcor.io/songs/src/models"
+)
+
+var (
+	ErrAlreadyVoted = errors.New("You already voted to skip this track")
+	ErrGameOver     = errors.New("The game is over")
+)
+
+// votesNeeded returns the number of votes skipping the current track. It must
+// be called with the room locked.
+func (r *Room) votesNeeded() int {
+	return int(float32(len(r.contestants()))*r.opts.SkipMajority) + 1
+}
+
+// VoteSkip records the vote of the player to skip the current track. Once a
+// majority of the players voted, the room moves on to the next track.
+func (r *Room) VoteSkip(playerId string) error {
+	if r.remoteOwner != "" {
+		_, err := r.forward(roomCommand{Action: skipAction, User: models.User{ID: playerId}})
+		return err
+	}
+	defer r.changed()
+
+	r.touch()
+
+	r.mu.Lock()
+	player, exists := r.Players[playerId]
+	switch {
+	case !exists:
+		r.mu.Unlock()
+		return ErrNotInRoom
+	case player.Spectator:
+		r.mu.Unlock()
+		return ErrSpectator
+	case !r.finishedAt.IsZero() || len(r.PlayedTracks) == 0:
+		r.mu.Unlock()
+		return ErrGameOver
+	case r.roundOver:
+		r.mu.Unlock()
+		return ErrRoundOver
+	case r.skipVotes[playerId]:
+		r.mu.Unlock()
+		return ErrAlreadyVoted
+	}
+
+	if r.skipVotes == nil {
+		r.skipVotes = map[string]bool{}
+	}
+	r.skipVotes[playerId] = true
+	event := RoomEvent{Kind: SkipVoteEvent, Votes: len(r.skipVotes), VotesNeeded: r.votesNeeded()}
+	if event.Votes >= event.VotesNeeded {
+		// The game loop skips the track unless its round ended meanwhile
+		select {
+		case r.skips <- r.PlayedTracks[len(r.PlayedTracks)-1].Name:
+		default:
+		}
+	}
+	r.mu.Unlock()
+
+	r.publish(event)
+	return nil
+}
+
+// skipTrack cancels the points earned on the current track if it is still
+// the one voted to be skipped and its round did not end meanwhile, reporting
+// whether it was skipped.
+func (r *Room) skipTrack(track string) bool {
+	r.mu.Lock()
+	current := r.PlayedTracks[len(r.PlayedTracks)-1]
+	if current.Name != track || r.roundOver {
+		r.mu.Unlock()
+		return false
+	}
+
+	answers := r.answersFor(current)
+	for _, player := range r.Players {
+		if guess, exists := player.Guesses[track]; exists {
+			player.score -= guess.score
+		}
+		player.Guesses[track] = answers.newResult()
+	}
+	delete(r.finders, track)
+	r.skipped = append(r.skipped, current.ID)
+	scores, teamScores := r.scores(), r.teamScores()
+	r.mu.Unlock()
+
+	// Kept in the logs to clean the playlist up later
+	log.Infof("Track %s (%s) of playlist %s skipped in room %s", current.ID, current.Name, r.Playlist.ID, r.Id)
+
+	r.publish(RoomEvent{Kind: SkipEvent, Track: current})
+	r.publish(RoomEvent{Kind: ScoresEvent, Scores: scores, TeamScores: teamScores})
+	return true
+}
+
+// Skipped returns the ids of the tracks skipped by the players.
+func (r *Room) Skipped() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.skipped...)
+}
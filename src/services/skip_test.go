@@ -0,0 +1,96 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"lcor.io/songs/src/models"
+)
+
+func TestVoteSkip(t *testing.T) {
+	clock := newFakeClock()
+	room := newTestRoom(t, testPlaylist(3), WithClock(clock))
+	defer room.Close()
+	events, unsubscribe := room.Events()
+	defer unsubscribe()
+	for _, id := range []string{"user-0", "user-1", "user-2"} {
+		room.AddPlayer(&models.User{ID: id, Name: "User " + id[len(id)-1:]})
+	}
+	track := nextTrack(t, events)
+	room.GuessResult("user-0", track.Name)
+
+	if err := room.VoteSkip("user-0"); err != nil {
+		t.Fatalf("vote error = %v", err)
+	}
+	if event := nextEvent(t, events, SkipVoteEvent); event.Votes != 1 || event.VotesNeeded != 2 {
+		t.Errorf("vote event %+v; want 1 of 2 votes", event)
+	}
+	if err := room.VoteSkip("user-0"); err != ErrAlreadyVoted {
+		t.Errorf("second vote error = %v; want %v", err, ErrAlreadyVoted)
+	}
+
+	// The majority moves on at once, cancelling the points of the track
+	room.VoteSkip("user-1")
+	if event := nextEvent(t, events, SkipEvent); event.Track.ID != track.ID {
+		t.Errorf("skip event %+v; want %s skipped", event, track.ID)
+	}
+	if next := nextTrack(t, events); next.Name == track.Name {
+		t.Errorf("next track %s; want another track", next.Name)
+	}
+	if players := room.Snapshot().Players; slices.ContainsFunc(players, func(p PlayerSnapshot) bool { return p.Score != 0 }) {
+		t.Errorf("players %+v; want no points for the skipped track", players)
+	}
+	if skipped := room.Skipped(); !slices.Equal(skipped, []string{track.ID}) {
+		t.Errorf("skipped %v; want [%s]", skipped, track.ID)
+	}
+
+	// Votes are counted again for each track
+	if err := room.VoteSkip("user-0"); err != nil {
+		t.Errorf("vote on the next track error = %v", err)
+	}
+}
+
+func TestVotesNeeded(t *testing.T) {
+	testcases := []struct {
+		players  int
+		majority float32
+		want     int
+	}{
+		{1, 0.5, 1},
+		{2, 0.5, 2},
+		{3, 0.5, 2},
+		{4, 0.5, 3},
+		{4, 0, 1},
+		{4, 1, 5},
+	}
+
+	for _, tc := range testcases {
+		room := startRound(t, benchmarkTrack, tc.players, WithSkipMajority(tc.majority))
+		room.mu.Lock()
+		needed := room.votesNeeded()
+		room.mu.Unlock()
+		if needed != tc.want {
+			t.Errorf("%d players at %v: %d votes needed; want %d", tc.players, tc.majority, needed, tc.want)
+		}
+	}
+}
+
+func TestSkipTrackAfterRoundEnd(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 1)
+	defer room.Close()
+	room.GuessResult("user-0", benchmarkTrack.Name)
+
+	// The track was found by everyone while the votes were counted
+	room.mu.Lock()
+	room.roundOver = true
+	room.mu.Unlock()
+	if room.skipTrack(benchmarkTrack.Name) {
+		t.Error("skipTrack() = true; want the ended round kept")
+	}
+	if skipped := room.Skipped(); len(skipped) != 0 {
+		t.Errorf("skipped %v; want none", skipped)
+	}
+	if players := room.Snapshot().Players; players[0].Score == 0 {
+		t.Errorf("players %+v; want the points of the round kept", players)
+	}
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"lcor.io/songs/src/models"
+)
+
+// RoomSnapshot is the serializable state of a room, used to restore unfinished
+// rooms across server restarts. The current round is the last played track.
+type RoomSnapshot struct {
+	Id           string
+	Opts         RoomOpts
+	Playlist     models.Playlist
+	PlayedTracks []models.Track
+	Elapsed      time.Duration // Time elapsed in the current round
+	Players      []PlayerSnapshot
+	Finders      map[string]map[string][]Finder
+	Choices      *roundChoices     // Options of the current multiple-choice round
+	Teams        map[string]string // Team of every player who joined
+	Rounds       []RoundScores
+	Skipped      []string // Ids of the tracks skipped by the players
+	Round        Round
+	Version      uint64 // Changes made to the room, telling its snapshots apart
+}
+
+type PlayerSnapshot struct {
+	Id        string
+	Name      string
+	PlayerId  string
+	Team      string
+	Spectator bool
+	Score     float32
+	Attempts  int // Guesses sent during the current round
+	Guesses   map[string]GuessSnapshot
+}
+
+type GuessSnapshot struct {
+	Title        ResultValidity
+	Artists      map[string]ResultValidity
+	Fields       map[string]ResultValidity
+	Score        float32
+	Bonus        float32
+	Ranks        map[string]int
+	Points       map[string]float32
+	Penalty      float32
+	ResponseTime time.Duration
+	Choice       string
+	Year         int
+}
+
+func (g *GuessResult) snapshot() GuessSnapshot {
+	return GuessSnapshot{
+		Title:        g.Title,
+		Artists:      maps.Clone(g.Artists),
+		Fields:       maps.Clone(g.Fields),
+		Score:        g.score,
+		Bonus:        g.bonus,
+		Ranks:        maps.Clone(g.ranks),
+		Points:       maps.Clone(g.points),
+		Penalty:      g.penalty,
+		ResponseTime: g.responseTime,
+		Choice:       g.choice,
+		Year:         g.year,
+	}
+}
+
+func (g GuessSnapshot) restore() *GuessResult {
+	return &GuessResult{
+		Title:        g.Title,
+		Artists:      maps.Clone(g.Artists),
+		Fields:       maps.Clone(g.Fields),
+		score:        g.Score,
+		bonus:        g.Bonus,
+		ranks:        maps.Clone(g.Ranks),
+		points:       maps.Clone(g.Points),
+		penalty:      g.Penalty,
+		responseTime: g.ResponseTime,
+		choice:       g.Choice,
+		year:         g.Year,
+	}
+}
+
+// Snapshot captures the current state of the room.
+func (r *Room) Snapshot() RoomSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := RoomSnapshot{
+		Id:           r.Id,
+		Opts:         r.opts,
+		Playlist:     *r.Playlist,
+		PlayedTracks: append([]models.Track(nil), r.PlayedTracks...),
+		Players:      make([]PlayerSnapshot, 0, len(r.Players)),
+		Finders:      make(map[string]map[string][]Finder, len(r.finders)),
+		Choices:      r.choices,
+		Version:      r.version,
+		Teams:        maps.Clone(r.teams),
+		Rounds:       slices.Clone(r.rounds),
+		Skipped:      slices.Clone(r.skipped),
+		Round:        r.round,
+	}
+	// Restored rooms waiting for their players stay where they were left
+	switch {
+	case !r.started:
+		snapshot.Elapsed = r.resumeElapsed
+	case len(r.PlayedTracks) > 0:
+		snapshot.Elapsed = r.roundElapsed()
+	}
+
+	for track, fields := range r.finders {
+		snapshot.Finders[track] = make(map[string][]Finder, len(fields))
+		for field, finders := range fields {
+			snapshot.Finders[track][field] = slices.Clone(finders)
+		}
+	}
+
+	for _, player := range r.Players {
+		guesses := make(map[string]GuessSnapshot, len(player.Guesses))
+		for track, guess := range player.Guesses {
+			guesses[track] = guess.snapshot()
+		}
+		snapshot.Players = append(snapshot.Players, PlayerSnapshot{
+			Id:        player.Id,
+			Name:      player.Name,
+			PlayerId:  player.PlayerId,
+			Team:      player.Team,
+			Spectator: player.Spectator,
+			Score:     player.score,
+			Attempts:  player.attempts,
+			Guesses:   guesses,
+		})
+	}
+
+	return snapshot
+}
+
+// RestoreRoom rebuilds a room from a snapshot. Its game resumes with the first
+// reconnection.
+func RestoreRoom(snapshot RoomSnapshot, opts ...roomOptFunc) (*Room, error) {
+	restoreOpts := func(o *RoomOpts) {
+		clock, bus := o.Clock, o.Bus
+		*o = snapshot.Opts
+		o.Clock, o.Bus = clock, bus
+	}
+	room, err := NewRoom(snapshot.Playlist, append([]roomOptFunc{restoreOpts}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("Error restoring room %s: %v", snapshot.Id, err)
+	}
+
+	room.Id = snapshot.Id
+	room.PlayedTracks = append(room.PlayedTracks, snapshot.PlayedTracks...)
+	room.roundStartedAt = room.opts.Clock.Now().Add(-snapshot.Elapsed)
+	room.resumeElapsed = snapshot.Elapsed
+	room.choices = snapshot.Choices
+	maps.Copy(room.teams, snapshot.Teams)
+	room.rounds = slices.Clone(snapshot.Rounds)
+	room.skipped = slices.Clone(snapshot.Skipped)
+	room.round = snapshot.Round
+	room.version = snapshot.Version
+
+	for _, p := range snapshot.Players {
+		player := Player{
+			Id:        p.Id,
+			Name:      p.Name,
+			PlayerId:  p.PlayerId,
+			Team:      p.Team,
+			Spectator: p.Spectator,
+			Guesses:   make(map[string]*GuessResult, len(p.Guesses)),
+			score:     p.Score,
+			attempts:  p.Attempts,
+		}
+		for track, guess := range p.Guesses {
+			player.Guesses[track] = guess.restore()
+		}
+		room.Players[p.PlayerId] = &player
+	}
+
+	for track, fields := range snapshot.Finders {
+		room.finders[track] = make(map[string][]Finder, len(fields))
+		for field, finders := range fields {
+			room.finders[track][field] = slices.Clone(finders)
+		}
+	}
+
+	return room, nil
+}
@@ -15,8 +15,9 @@ type SpotifyTrack struct {
 		Spotify string `json:"spotify"`
 	} `json:"external_urls"`
 	Album struct {
-		Name   string `json:"name"`
-		Images []struct {
+		Name        string `json:"name"`
+		ReleaseDate string `json:"release_date"`
+		Images      []struct {
 			Height int    `json:"height"`
 			Url    string `json:"url"`
 			Width  int    `json:"width"`
@@ -95,11 +96,13 @@ func (s SpotifyPlaylist) ToPlaylist() models.Playlist {
 			tracks := make([]models.Track, 0, len(s.Tracks.Items))
 			for _, track := range s.Tracks.Items {
 				tracks = append(tracks, models.Track{
-					ID:         track.Track.Id,
-					Name:       track.Track.Name,
-					Link:       track.Track.ExternalUrls.Spotify,
-					PreviewUrl: track.Track.PreviewUrl,
-					Client:     models.Spotify,
+					ID:          track.Track.Id,
+					Name:        track.Track.Name,
+					Album:       track.Track.Album.Name,
+					ReleaseDate: track.Track.Album.ReleaseDate,
+					Link:        track.Track.ExternalUrls.Spotify,
+					PreviewUrl:  track.Track.PreviewUrl,
+					Client:      models.Spotify,
 					Image: models.Image{
 						Url:    track.Track.Album.Images[0].Url,
 						Width:  track.Track.Album.Images[0].Width,
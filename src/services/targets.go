@@ -0,0 +1,110 @@
+package services
+
+import (
+	"strconv"
+
+	"lcor.io/songs/src/models"
+	"lcor.io/songs/src/utils"
+)
+
+// Target is an optional field of the tracks players can guess, on top of
+// their title and artists.
+type Target string
+
+const (
+	AlbumTarget    Target = "album"
+	YearTarget     Target = "year"
+	FeaturedTarget Target = "featured" // Artists other than the first one
+)
+
+const (
+	albumField = "album"
+	yearField  = "year"
+
+	// Share of the points of a title or artist earned by an optional field
+	targetWeight = 0.5
+)
+
+func featuredField(artist string) string {
+	return "featured:" + artist
+}
+
+// releaseYear returns the year a track was released, or 0 if unknown.
+func releaseYear(track models.Track) int {
+	if len(track.ReleaseDate) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(track.ReleaseDate[:4])
+	if err != nil {
+		return 0
+	}
+	return year
+}
+
+// yearScore scores the year of a guess against the release year, accepting it
+// within tolerance years. Only the first year of the guess is judged, so
+// players cannot list a whole decade at once.
+func yearScore(phrases guessPhrases, year, tolerance int) float32 {
+	if len(phrases) == 0 {
+		return 0
+	}
+	for _, word := range phrases[0] {
+		guessed, err := strconv.Atoi(word)
+		if err != nil || len(word) != 4 {
+			continue
+		}
+		if guessed >= year-tolerance && guessed <= year+tolerance {
+			return 100
+		}
+		return 0
+	}
+	return 0
+}
+
+// newResult returns the result of a player who did not guess the track yet.
+func (a *trackAnswers) newResult() *GuessResult {
+	result := &GuessResult{
+		Title:   Invalid,
+		Artists: make(map[string]ResultValidity, len(a.artists)),
+	}
+	for artist := range a.artists {
+		result.Artists[artist] = Invalid
+	}
+	if len(a.targets) > 0 || a.year > 0 {
+		result.Fields = make(map[string]ResultValidity, len(a.targets)+1)
+		for field := range a.targets {
+			result.Fields[field] = Invalid
+		}
+		if a.year > 0 {
+			result.Fields[yearField] = Invalid
+		}
+	}
+	return result
+}
+
+// TargetResult is the result of a player on an optional field of a track.
+type TargetResult struct {
+	Label    string
+	Answer   string
+	Validity ResultValidity
+	Rank     int // Rank of the player among the ones who found the field
+}
+
+// TargetResults returns the results of the player on the optional fields of
+// the track, in display order.
+func (g GuessResult) TargetResults(track models.Track) []TargetResult {
+	results := make([]TargetResult, 0, len(g.Fields))
+	if validity, exists := g.Fields[albumField]; exists {
+		results = append(results, TargetResult{"Album", track.Album, validity, g.ranks[albumField]})
+	}
+	if validity, exists := g.Fields[yearField]; exists {
+		results = append(results, TargetResult{"Year", strconv.Itoa(releaseYear(track)), validity, g.ranks[yearField]})
+	}
+	for _, artist := range track.Artists {
+		field := featuredField(utils.Normalize(artist.Name))
+		if validity, exists := g.Fields[field]; exists {
+			results = append(results, TargetResult{"Featured artist", artist.Name, validity, g.ranks[field]})
+		}
+	}
+	return results
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"lcor.io/songs/src/models"
+)
+
+var targetsTrack = models.Track{
+	ID:          "pressure",
+	Name:        "Under Pressure",
+	Album:       "Hot Space",
+	ReleaseDate: "1982-05-21",
+	Artists: []models.Artist{
+		{ID: "queen", Name: "Queen"},
+		{ID: "bowie", Name: "David Bowie"},
+	},
+}
+
+func TestGuessTargets(t *testing.T) {
+	room := startRound(t, targetsTrack, 1, WithTargets(AlbumTarget, YearTarget, FeaturedTarget), WithFinderBonuses())
+
+	testcases := []struct {
+		guess string
+		field string
+		want  ResultValidity
+		score float32
+	}{
+		{"hot space", albumField, Valid, 50},
+		{"1990", yearField, Invalid, 50},
+		{"1980", yearField, Valid, 100},
+		{"david bowie", featuredField("david bowie"), Valid, 150},
+		{"queen", featuredField("david bowie"), Valid, 250},
+	}
+
+	for _, tc := range testcases {
+		result, err := room.GuessResult("user-0", tc.guess)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Fields[tc.field] != tc.want || result.score != tc.score {
+			t.Errorf("guess %q: %s = %v, score %v; want %v, %v", tc.guess, tc.field, result.Fields[tc.field], result.score, tc.want, tc.score)
+		}
+	}
+
+	// Featured artists are only guessable as such
+	result := *room.Players["user-0"].Guesses[targetsTrack.Name]
+	if _, exists := result.Artists["david bowie"]; exists {
+		t.Errorf("artists = %v; want featured artists apart", result.Artists)
+	}
+	want := []TargetResult{
+		{"Album", "Hot Space", Valid, 1},
+		{"Year", "1982", Valid, 1},
+		{"Featured artist", "David Bowie", Valid, 1},
+	}
+	if results := result.TargetResults(targetsTrack); !slices.Equal(results, want) {
+		t.Errorf("TargetResults() = %v; want %v", results, want)
+	}
+}
+
+func TestTrackAnswersTargets(t *testing.T) {
+	single := targetsTrack
+	single.Album = "Under Pressure (Remastered)"
+
+	answers := newTrackAnswers(single, []Target{AlbumTarget, YearTarget})
+	if _, exists := answers.targets[albumField]; exists {
+		t.Error("albums named after the track should not be guessed")
+	}
+	if len(answers.artists) != 2 || answers.year != 1982 {
+		t.Errorf("artists = %v, year %d; want both artists and 1982", answers.artists, answers.year)
+	}
+	if result := answers.newResult(); result.Fields[yearField] != Invalid || len(result.Fields) != 1 {
+		t.Errorf("new result fields = %v; want the year only", result.Fields)
+	}
+}
+
+func TestReleaseYear(t *testing.T) {
+	testcases := []struct {
+		date string
+		want int
+	}{
+		{"1982-05-21", 1982},
+		{"1982-05", 1982},
+		{"1982", 1982},
+		{"", 0},
+		{"0000", 0},
+		{"unknown", 0},
+	}
+
+	for _, tc := range testcases {
+		if year := releaseYear(models.Track{ReleaseDate: tc.date}); year != tc.want {
+			t.Errorf("releaseYear(%q) = %d; want %d", tc.date, year, tc.want)
+		}
+	}
+}
+
+func TestYearScore(t *testing.T) {
+	testcases := []struct {
+		guess string
+		want  float32
+	}{
+		{"1982", 100},
+		{"queen 1980", 100},
+		{"99 luftballons 1983", 100},
+		{"1970 1982", 0},
+		{"1975 1980 1985 1990", 0},
+		{"queen", 0},
+	}
+
+	for _, tc := range testcases {
+		if score := yearScore(segmentGuess(tc.guess, 1), 1982, 2); score != tc.want {
+			t.Errorf("yearScore(%q) = %v; want %v", tc.guess, score, tc.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"lcor.io/songs/src/models"
+)
+
+// Messages are refused past this many characters
+const maxChatLength = 300
+
+var (
+	ErrUnknownTeam     = errors.New("Unknown team")
+	ErrNotInRoom       = errors.New("You are not in this room")
+	ErrNoTeam          = errors.New("You are not in a team")
+	ErrMessageTooLong  = errors.New("Message is too long")
+	ErrTeamsNotEnabled = errors.New("This room is not played in teams")
+)
+
+// ChatMessage is a message sent by a player to their teammates.
+type ChatMessage struct {
+	From string
+	Text string
+	At   time.Time
+}
+
+// assignTeam puts a joining player back in the team they were in before, or
+// in the team with the fewest players. It must be called with the room locked.
+func (r *Room) assignTeam(player *Player) {
+	if len(r.opts.Teams) == 0 {
+		return
+	}
+	if team, exists := r.teams[player.PlayerId]; exists && slices.Contains(r.opts.Teams, team) {
+		player.Team = team
+		return
+	}
+
+	sizes := make(map[string]int, len(r.opts.Teams))
+	for _, p := range r.Players {
+		sizes[p.Team]++
+	}
+	team := r.opts.Teams[0]
+	for _, t := range r.opts.Teams[1:] {
+		if sizes[t] < sizes[team] {
+			team = t
+		}
+	}
+	player.Team = team
+	r.teams[player.PlayerId] = team
+}
+
+// JoinTeam moves the player to one of the teams of the room.
+func (r *Room) JoinTeam(playerId, team string) error {
+	if r.remoteOwner != "" {
+		_, err := r.forward(roomCommand{Action: teamAction, User: models.User{ID: playerId}, Team: team})
+		return err
+	}
+	defer r.changed()
+
+	r.touch()
+
+	r.mu.Lock()
+	if !slices.Contains(r.opts.Teams, team) {
+		r.mu.Unlock()
+		return ErrUnknownTeam
+	}
+	player, exists := r.Players[playerId]
+	if !exists {
+		r.mu.Unlock()
+		return ErrNotInRoom
+	}
+	player.Team = team
+	r.teams[playerId] = team
+	scores, teamScores := r.scores(), r.teamScores()
+	r.mu.Unlock()
+
+	// The team standings changed with their members
+	r.publish(RoomEvent{Kind: ScoresEvent, Scores: scores, TeamScores: teamScores})
+	return nil
+}
+
+// TeamOf returns the team of the player, empty if the room is not played in
+// teams.
+func (r *Room) TeamOf(playerId string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.teams[playerId]
+}
+
+// Teams returns the teams of the room, in the order they were declared.
+func (r *Room) Teams() []string {
+	return slices.Clone(r.opts.Teams)
+}
+
+// teamScores returns the scores of the teams, adding up the scores of their
+// members, best first. It must be called with the room locked.
+func (r *Room) teamScores() []Score {
+	if len(r.opts.Teams) == 0 {
+		return nil
+	}
+
+	totals := make(map[string]float32, len(r.opts.Teams))
+	for _, player := range r.Players {
+		totals[player.Team] += player.score
+	}
+	scores := make([]Score, 0, len(r.opts.Teams))
+	for _, team := range r.opts.Teams {
+		scores = append(scores, Score{team, totals[team]})
+	}
+	slices.SortStableFunc(scores, func(a, b Score) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return scores
+}
+
+// SendTeamMessage sends a chat message to the teammates of the player.
+func (r *Room) SendTeamMessage(playerId, text string) error {
+	if r.remoteOwner != "" {
+		_, err := r.forward(roomCommand{Action: chatAction, User: models.User{ID: playerId}, Message: text})
+		return err
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(text) > maxChatLength {
+		return ErrMessageTooLong
+	}
+	if len(r.opts.Teams) == 0 {
+		return ErrTeamsNotEnabled
+	}
+
+	r.touch()
+
+	r.mu.Lock()
+	player, exists := r.Players[playerId]
+	if !exists {
+		r.mu.Unlock()
+		return ErrNotInRoom
+	}
+	if player.Team == "" {
+		r.mu.Unlock()
+		return ErrNoTeam
+	}
+	event := RoomEvent{
+		Kind:    ChatEvent,
+		Team:    player.Team,
+		Message: &ChatMessage{From: player.Name, Text: text, At: r.opts.Clock.Now()},
+	}
+	r.mu.Unlock()
+
+	r.publish(event)
+	return nil
+}
+
+// validTeams drops the empty and duplicate team names.
+func validTeams(teams []string) []string {
+	valid := make([]string, 0, len(teams))
+	for _, team := range teams {
+		team = strings.TrimSpace(team)
+		if team == "" || slices.Contains(valid, team) {
+			continue
+		}
+		valid = append(valid, team)
+	}
+	return valid
+}
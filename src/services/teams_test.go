@@ -0,0 +1,96 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"lcor.io/songs/src/models"
+)
+
+func TestAssignTeam(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 5, WithTeams("Red", "Blue", " ", "Red"))
+
+	if teams := room.Teams(); !slices.Equal(teams, []string{"Red", "Blue"}) {
+		t.Fatalf("teams %v; want [Red Blue]", teams)
+	}
+	sizes := map[string]int{}
+	for _, player := range room.Players {
+		sizes[player.Team]++
+	}
+	if sizes["Red"] != 3 || sizes["Blue"] != 2 {
+		t.Errorf("team sizes %v; want 3 Red and 2 Blue", sizes)
+	}
+
+	if err := room.JoinTeam("user-0", "Green"); err != ErrUnknownTeam {
+		t.Errorf("unknown team error = %v; want %v", err, ErrUnknownTeam)
+	}
+	if err := room.JoinTeam("user-0", "Blue"); err != nil {
+		t.Fatalf("join team error = %v", err)
+	}
+
+	// Players are back in their team when they reconnect
+	nonce := room.Connect("user-0")
+	room.RemovePlayer("user-0", nonce)
+	room.AddPlayer(&models.User{ID: "user-0", Name: "User 0"})
+	if team := room.Players["user-0"].Team; team != "Blue" {
+		t.Errorf("team after reconnection %q; want Blue", team)
+	}
+}
+
+func TestTeamScores(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 4, WithTeams("Red", "Blue"))
+
+	// Only the first finder of each team earns a bonus, ranked among teams
+	for _, tc := range []struct {
+		player string
+		want   float32
+	}{
+		{"user-0", 150},
+		{"user-2", 100},
+		{"user-1", 125},
+		{"user-3", 100},
+	} {
+		result, err := room.GuessResult(tc.player, "dont stop me now")
+		if err != nil || result.score != tc.want {
+			t.Errorf("%s (%s) score = %v, %v; want %v", tc.player, room.TeamOf(tc.player), result.score, err, tc.want)
+		}
+	}
+
+	room.mu.Lock()
+	scores := room.teamScores()
+	room.mu.Unlock()
+	if want := []Score{{"Red", 250}, {"Blue", 225}}; !slices.Equal(scores, want) {
+		t.Errorf("team scores %v; want %v", scores, want)
+	}
+
+	snapshot, err := RestoreRoom(room.Snapshot())
+	if err != nil {
+		t.Fatalf("RestoreRoom() = %v", err)
+	}
+	if team := snapshot.TeamOf("user-1"); team != "Blue" {
+		t.Errorf("restored team %q; want Blue", team)
+	}
+}
+
+func TestSendTeamMessage(t *testing.T) {
+	room := startRound(t, benchmarkTrack, 2, WithTeams("Red", "Blue"))
+	events, unsubscribe := room.Events()
+	defer unsubscribe()
+
+	if err := room.SendTeamMessage("user-1", strings.Repeat("a", maxChatLength+1)); err != ErrMessageTooLong {
+		t.Errorf("long message error = %v; want %v", err, ErrMessageTooLong)
+	}
+	if err := room.SendTeamMessage("user-1", " go go go "); err != nil {
+		t.Fatalf("message error = %v", err)
+	}
+	event := <-events
+	if event.Kind != ChatEvent || event.Team != "Blue" || event.Message.From != "User 1" || event.Message.Text != "go go go" {
+		t.Errorf("chat event %+v; want message of User 1 to Blue", event)
+	}
+
+	solo := startRound(t, benchmarkTrack, 1)
+	if err := solo.SendTeamMessage("user-0", "hello"); err != ErrTeamsNotEnabled {
+		t.Errorf("message without teams error = %v; want %v", err, ErrTeamsNotEnabled)
+	}
+}
@@ -1,35 +1,42 @@
 package services
 
 import (
-	"errors"
-
+	"github.com/gofiber/fiber/v3/log"
 	"github.com/google/uuid"
 	"lcor.io/songs/src/models"
 )
 
-var users = map[string]*models.User{}
+// Users are kept in the mansion backend, so every server instance knows them
+var users UserStore = &memoryUserStore{users: map[string]models.User{}}
 
 func UserExists(id string) bool {
-	_, exists := users[id]
-	return exists
+	_, err := users.GetUser(id)
+	return err == nil
 }
 
 func GetUser(id string) (*models.User, error) {
-	if user, exists := users[id]; exists {
-		return user, nil
+	return users.GetUser(id)
+}
+
+func CountUsers() int {
+	count, err := users.CountUsers()
+	if err != nil {
+		log.Errorf("Error counting users: %v", err)
 	}
-	return nil, errors.New("User not found")
+	return count
 }
 
-func GetUsers() map[string]*models.User {
-	return users
+// RegisterUser adds an already known user, keeping its identifier.
+func RegisterUser(user models.User) *models.User {
+	if err := users.SaveUser(user); err != nil {
+		log.Errorf("Error saving user %s: %v", user.ID, err)
+	}
+	return &user
 }
 
 func CreateUser(name string) *models.User {
-	user := models.User{
+	return RegisterUser(models.User{
 		ID:   uuid.NewString(),
 		Name: name,
-	}
-	users[user.ID] = &user
-	return &user
+	})
 }
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// Years off the release year at which a guess stops earning points
+const yearPointsRange = 10
+
+var (
+	ErrNotAYear    = errors.New("Guess a year, like 1985 or 80s")
+	ErrYearGuessed = errors.New("You already guessed the year of this track")
+)
+
+// parseYear reads the year of a guess. Two digit years are taken in the last
+// century unless they are not over yet, and decades, like "80s", count as
+// their middle year.
+func parseYear(guess string, currentYear int) (int, bool) {
+	guess = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(guess)), "'")
+	decade := strings.HasSuffix(guess, "s")
+	guess = strings.TrimSuffix(guess, "s")
+	if len(guess) != 2 && len(guess) != 4 {
+		return 0, false
+	}
+
+	year, err := strconv.Atoi(guess)
+	if err != nil || year < 0 {
+		return 0, false
+	}
+	if len(guess) == 2 {
+		if year <= currentYear%100 {
+			year += currentYear - currentYear%100
+		} else {
+			year += currentYear - currentYear%100 - 100
+		}
+	}
+	if decade {
+		if year%10 != 0 {
+			return 0, false
+		}
+		year += 5
+	}
+	return year, true
+}
+
+// guessYear judges the release year guessed by the player on the current
+// track. Each player guesses once per track, the closest guesses earning the
+// most points.
+func (r *Room) guessYear(playerId, guess string) (*GuessResult, error) {
+	defer r.changed()
+
+	r.touch()
+
+	r.mu.Lock()
+	if len(r.PlayedTracks) == 0 {
+		r.mu.Unlock()
+		return nil, ErrRoundNotStarted
+	}
+	currentTrack := r.PlayedTracks[len(r.PlayedTracks)-1]
+	player, exists := r.Players[playerId]
+	if !exists {
+		r.mu.Unlock()
+		return nil, ErrNotInRoom
+	}
+	oldGuessResult := player.Guesses[currentTrack.Name]
+	reject := func(err error) (*GuessResult, error) {
+		result := *oldGuessResult
+		r.mu.Unlock()
+		return &result, err
+	}
+
+	if err := r.admitGuess(player, guess); err != nil {
+		return reject(err)
+	}
+	if oldGuessResult.year != 0 {
+		return reject(ErrYearGuessed)
+	}
+	guessed, ok := parseYear(guess, r.opts.Clock.Now().Year())
+	if !ok {
+		return reject(ErrNotAYear)
+	}
+
+	responseTime := r.roundElapsed()
+	turn := Turn{
+		Elapsed:  responseTime,
+		Duration: r.opts.TrackDuration,
+		Streak:   r.streak(player),
+	}
+	newGuessResult := GuessResult{
+		Title:        oldGuessResult.Title,
+		Artists:      maps.Clone(oldGuessResult.Artists),
+		Fields:       maps.Clone(oldGuessResult.Fields),
+		ranks:        map[string]int{},
+		points:       map[string]float32{},
+		responseTime: responseTime,
+		year:         guessed,
+	}
+	if newGuessResult.Fields == nil {
+		newGuessResult.Fields = map[string]ResultValidity{}
+	}
+	newGuessResult.Fields[yearField] = Invalid
+
+	// Points fall with the distance to the release year. Years within the
+	// tolerance count as found, earning the finder bonus.
+	year := releaseYear(currentTrack)
+	distance := max(guessed-year, year-guessed)
+	closeness := float32(100*max(0, yearPointsRange-distance)) / yearPointsRange
+	switch {
+	case distance <= r.opts.YearTolerance:
+		newGuessResult.Fields[yearField] = Valid
+		rank, bonus := r.findField(currentTrack.Name, yearField, player)
+		newGuessResult.ranks[yearField] = rank
+		newGuessResult.bonus = bonus
+	case closeness > 0:
+		newGuessResult.Fields[yearField] = Partial
+	default:
+		newGuessResult.penalty = r.scoring.Penalty(turn)
+	}
+	newGuessResult.points[yearField] = r.scoring.Points(turn, closeness, distance == 0)
+	newGuessResult.score = newGuessResult.points[yearField] + newGuessResult.bonus - newGuessResult.penalty
+
+	player.score += newGuessResult.score - oldGuessResult.score
+	player.Guesses[currentTrack.Name] = &newGuessResult
+
+	var scores, teamScores []Score
+	if newGuessResult.score != oldGuessResult.score {
+		scores, teamScores = r.scores(), r.teamScores()
+	}
+	roundEnd := r.endRoundEarly()
+	r.mu.Unlock()
+
+	if scores != nil {
+		r.publish(RoomEvent{Kind: ScoresEvent, Scores: scores, TeamScores: teamScores})
+	}
+	if roundEnd != nil {
+		r.publish(*roundEnd)
+	}
+
+	return &newGuessResult, nil
+}
+
+// Year returns the release year guessed by the player, or 0.
+func (g GuessResult) Year() int {
+	return g.year
+}
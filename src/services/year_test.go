@@ -0,0 +1,134 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"lcor.io/songs/src/models"
+)
+
+func TestParseYear(t *testing.T) {
+	testcases := []struct {
+		guess string
+		want  int
+		ok    bool
+	}{
+		{"1979", 1979, true},
+		{" 2001 ", 2001, true},
+		{"79", 1979, true},
+		{"'19", 2019, true},
+		{"80s", 1985, true},
+		{"1990s", 1995, true},
+		{"'00s", 2005, true},
+		{"85s", 0, false},
+		{"197", 0, false},
+		{"seventies", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tc := range testcases {
+		if year, ok := parseYear(tc.guess, 2024); year != tc.want || ok != tc.ok {
+			t.Errorf("parseYear(%q) = %d, %v; want %d, %v", tc.guess, year, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestGuessYear(t *testing.T) {
+	track := benchmarkTrack
+	track.ReleaseDate = "1979-01-26"
+
+	testcases := []struct {
+		guess    string
+		validity ResultValidity
+		score    float32
+	}{
+		{"1979", Valid, 150},
+		{"81", Valid, 130},
+		{"70s", Partial, 60},
+		{"1995", Invalid, 0},
+	}
+
+	for _, tc := range testcases {
+		// A second player keeps the round going after the guess
+		room := startRound(t, track, 2, WithMode(YearMode))
+		result, err := room.GuessResult("user-0", tc.guess)
+		if err != nil {
+			t.Fatalf("guess %q error = %v", tc.guess, err)
+		}
+		if result.Fields[yearField] != tc.validity || result.score != tc.score || result.Year() == 0 {
+			t.Errorf("guess %q: year %v scoring %v; want %v scoring %v", tc.guess, result.Fields[yearField], result.score, tc.validity, tc.score)
+		}
+
+		// Players only get one guess by track
+		if _, err := room.GuessResult("user-0", "1979"); err != ErrYearGuessed {
+			t.Errorf("second guess error = %v; want %v", err, ErrYearGuessed)
+		}
+	}
+
+	room := startRound(t, track, 1, WithMode(YearMode))
+	if _, err := room.GuessResult("user-0", "Don't Stop Me Now"); err != ErrNotAYear {
+		t.Errorf("title guess error = %v; want %v", err, ErrNotAYear)
+	}
+	if result, err := room.GuessResult("stranger", "1979"); result != nil || err != ErrNotInRoom {
+		t.Errorf("guess of a non-member = %+v, %v; want %v", result, err, ErrNotInRoom)
+	}
+	if result, _ := room.GuessResult("user-0", "1978"); result.Fields[yearField] != Valid {
+		t.Errorf("year guessed after a title %v; want %v", result.Fields[yearField], Valid)
+	}
+}
+
+func TestChronologyRound(t *testing.T) {
+	playlist := testPlaylist(3)
+	for i, date := range []string{"1985-07-13", "1979", "2001-03"} {
+		playlist.Tracks[i].ReleaseDate = date
+	}
+	clock := newFakeClock()
+	room := newTestRoom(t, playlist, WithClock(clock), WithMode(YearMode), WithChronologyRound())
+	defer room.Close()
+	events, unsubscribe := room.Events()
+	defer unsubscribe()
+	for _, id := range []string{"user-0", "user-1", "user-2"} {
+		room.AddPlayer(&models.User{ID: id, Name: "User " + id[len(id)-1:]})
+	}
+
+	for range playlist.Tracks {
+		nextTrack(t, events)
+		clock.Advance(room.opts.TrackDuration)
+	}
+	chronology := nextEvent(t, events, ChronologyEvent).Chronology
+	if len(chronology) != 3 {
+		t.Fatalf("chronology %+v; want the 3 played tracks", chronology)
+	}
+
+	right := []string{"track-1", "track-0", "track-2"}
+	reversed := slices.Clone(right)
+	slices.Reverse(reversed)
+	if _, err := room.OrderTracks("user-1", []string{"track-1", "track-1", "track-0"}); err != ErrInvalidOrder {
+		t.Errorf("order with a duplicate error = %v; want %v", err, ErrInvalidOrder)
+	}
+	if points, err := room.OrderTracks("user-0", right); err != nil || points != chronologyPoints {
+		t.Errorf("right order = %v, %v; want %v", points, err, chronologyPoints)
+	}
+	if points, err := room.OrderTracks("user-1", reversed); err != nil || points != 0 {
+		t.Errorf("reversed order = %v, %v; want 0", points, err)
+	}
+	if _, err := room.OrderTracks("user-0", right); err != ErrAlreadyOrdered {
+		t.Errorf("second order error = %v; want %v", err, ErrAlreadyOrdered)
+	}
+
+	clock.Advance(room.opts.TrackDuration)
+	answer := nextEvent(t, events, ChronologyEndEvent).Chronology
+	ids := make([]string, 0, len(answer))
+	for _, choice := range answer {
+		ids = append(ids, choice.Id)
+	}
+	if !slices.Equal(ids, right) {
+		t.Errorf("revealed order %v; want %v", ids, right)
+	}
+	if _, err := room.OrderTracks("user-2", right); err != ErrRoundOver {
+		t.Errorf("order after the reveal error = %v; want %v", err, ErrRoundOver)
+	}
+	if _, err := room.GuessResult("user-0", "1985"); err != ErrRoundOver {
+		t.Errorf("guess after the game error = %v; want %v", err, ErrRoundOver)
+	}
+}
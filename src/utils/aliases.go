@@ -0,0 +1,118 @@
+package utils
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+//go:embed aliases.csv
+var bundledAliases string
+
+// AliasDictionary groups the alternative spellings, abbreviations and
+// numerals of artists and titles, so any name of a group matches the others.
+type AliasDictionary struct {
+	mu     sync.RWMutex
+	groups map[string][]string // Every normalized name of the group of a name
+}
+
+func NewAliasDictionary() *AliasDictionary {
+	return &AliasDictionary{groups: map[string][]string{}}
+}
+
+// LoadAliases builds a dictionary from groups of aliases keyed by their
+// canonical name.
+func LoadAliases(groups map[string][]string) *AliasDictionary {
+	d := NewAliasDictionary()
+	for canonical, aliases := range groups {
+		d.Add(canonical, aliases...)
+	}
+	return d
+}
+
+// Add makes aliases equivalent to canonical, merging their existing groups.
+func (d *AliasDictionary) Add(canonical string, aliases ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	names := append([]string{canonical}, aliases...)
+	group := make([]string, 0, len(names))
+	for _, name := range names {
+		name = Normalize(name)
+		if name == "" {
+			continue
+		}
+		if existing, exists := d.groups[name]; exists {
+			group = append(group, existing...)
+		} else {
+			group = append(group, name)
+		}
+	}
+	slices.Sort(group)
+	group = slices.Compact(group)
+
+	for _, name := range group {
+		d.groups[name] = group
+	}
+}
+
+// Names returns the given normalized name followed by its aliases.
+func (d *AliasDictionary) Names(name string) []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	names := []string{name}
+	for _, alias := range d.groups[name] {
+		if alias != name {
+			names = append(names, alias)
+		}
+	}
+	return names
+}
+
+// Len returns the number of names in the dictionary.
+func (d *AliasDictionary) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return len(d.groups)
+}
+
+// ParseAliases reads groups of aliases as CSV lines, the canonical name
+// first.
+func ParseAliases(r io.Reader) (map[string][]string, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	aliases := map[string][]string{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return aliases, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing aliases: %v", err)
+		}
+		if len(record) < 2 {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("Error parsing aliases: line %d has no alias", line)
+		}
+		aliases[record[0]] = append(aliases[record[0]], record[1:]...)
+	}
+}
+
+// BundledAliases returns the aliases shipped with the game.
+func BundledAliases() map[string][]string {
+	aliases, err := ParseAliases(strings.NewReader(bundledAliases))
+	if err != nil {
+		panic(err)
+	}
+	return aliases
+}
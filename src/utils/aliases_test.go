@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAliasDictionary(t *testing.T) {
+	d := NewAliasDictionary()
+	d.Add("AC/DC", "acdc")
+	d.Add("Tupac", "2pac")
+	d.Add("2Pac", "Makaveli")
+
+	testcases := []struct {
+		in   string
+		want []string
+	}{
+		{"ac dc", []string{"ac dc", "acdc"}},
+		{"acdc", []string{"acdc", "ac dc"}},
+		{"makaveli", []string{"makaveli", "2pac", "tupac"}},
+		{"queen", []string{"queen"}},
+	}
+
+	for _, tc := range testcases {
+		if names := d.Names(tc.in); !slices.Equal(names, tc.want) {
+			t.Errorf("Names(%q) = %q; want %q", tc.in, names, tc.want)
+		}
+	}
+}
+
+func TestParseAliases(t *testing.T) {
+	aliases, err := ParseAliases(strings.NewReader("# comment\nAC/DC,acdc, ac dc\n\"Earth, Wind & Fire\",ewf\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(aliases["AC/DC"], []string{"acdc", "ac dc"}) || !slices.Equal(aliases["Earth, Wind & Fire"], []string{"ewf"}) {
+		t.Errorf("ParseAliases = %q", aliases)
+	}
+
+	if _, err := ParseAliases(strings.NewReader("Queen\n")); err == nil {
+		t.Error("ParseAliases should reject groups without alias")
+	}
+
+	if LoadAliases(BundledAliases()).Len() == 0 {
+		t.Error("Bundled aliases are empty")
+	}
+}
@@ -1,16 +1,12 @@
 PRAGMA foreign_keys = ON;
 
-DROP TABLE IF EXISTS rooms;
-
-CREATE TABLE rooms (
+CREATE TABLE IF NOT EXISTS rooms (
   id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
   likes INTEGER DEFAULT 0,
   created_on DATE DEFAULT CURRENT_TIMESTAMP
 );
 
-DROP TABLE IF EXISTS room_opts;
-
-CREATE TABLE room_opts (
+CREATE TABLE IF NOT EXISTS room_opts (
   id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
   track_duration REAL NOT NULL CHECK (track_duration > 0),
   guess_vality_threshold INTEGER NOT NULL,
@@ -20,9 +16,7 @@ CREATE TABLE room_opts (
   CONSTRAINT fk_room FOREIGN KEY (room_id) REFERENCES rooms (id) ON DELETE CASCADE
 );
 
-DROP TABLE IF EXISTS playlists;
-
-CREATE TABLE playlists (
+CREATE TABLE IF NOT EXISTS playlists (
   id INTEGER PRIMARY KEY AUTOINCREMENT,
   name TEXT NOT NULL,
   client_id TEXT NOT NULL,
@@ -30,9 +24,7 @@ CREATE TABLE playlists (
   CONSTRAINT fk_room FOREIGN KEY (room_id) REFERENCES rooms (id) ON DELETE CASCADE
 );
 
-DROP TABLE IF EXISTS artists_to_tracks;
-
-CREATE TABLE artists_to_tracks (
+CREATE TABLE IF NOT EXISTS artists_to_tracks (
   artist_id INTEGER,
   track_id INTEGER,
   PRIMARY KEY (artist_id, track_id),
@@ -40,18 +32,14 @@ CREATE TABLE artists_to_tracks (
   CONSTRAINT fk_track FOREIGN KEY (track_id) REFERENCES tracks (id) ON DELETE CASCADE
 );
 
-DROP TABLE IF EXISTS artists;
-
-CREATE TABLE artists (
+CREATE TABLE IF NOT EXISTS artists (
   id INTEGER PRIMARY KEY AUTOINCREMENT,
   name TEXT NOT NULL,
   client TEXT NOT NULL,
   link TEXT
 );
 
-DROP TABLE IF EXISTS tracks;
-
-CREATE TABLE tracks (
+CREATE TABLE IF NOT EXISTS tracks (
   id INTEGER PRIMARY KEY AUTOINCREMENT,
   name TEXT NOT NULL,
   client TEXT NOT NULL,
@@ -60,3 +48,20 @@ CREATE TABLE tracks (
   playlist_id INTEGER NOT NULL,
   CONSTRAINT fk_playlist FOREIGN KEY (playlist_id) REFERENCES playlists (id) ON DELETE CASCADE
 );
+
+CREATE TABLE IF NOT EXISTS room_snapshots (
+  room_id TEXT NOT NULL PRIMARY KEY,
+  state TEXT NOT NULL,
+  saved_on DATE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+  key TEXT NOT NULL PRIMARY KEY,
+  value BLOB NOT NULL,
+  expires_on INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS aliases (
+  alias TEXT NOT NULL PRIMARY KEY,
+  canonical TEXT NOT NULL
+);
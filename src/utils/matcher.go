@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Matcher scores how close a guess is to a reference string. Both are
+// expected to be normalized. The score is a float32 between 0 and 100.
+type Matcher interface {
+	Score(guess, reference string) float32
+}
+
+// Names of the available matching algorithms
+const (
+	LevenshteinMatcher = "levenshtein"
+	JaroWinklerMatcher = "jaro-winkler"
+	TokenSetMatcher    = "token-set"
+	PhoneticMatcher    = "phonetic"
+)
+
+var matchers = map[string]Matcher{
+	LevenshteinMatcher: Levenshtein{},
+	JaroWinklerMatcher: JaroWinkler{},
+	TokenSetMatcher:    TokenSet{},
+	PhoneticMatcher:    Phonetic{},
+}
+
+// MatcherWeight selects a matching algorithm by name, weighted against the
+// other algorithms of a combination.
+type MatcherWeight struct {
+	Name   string
+	Weight float32
+}
+
+// NewMatcher builds the weighted combination of the given algorithms. Without
+// any algorithm, it falls back to Levenshtein.
+func NewMatcher(weights ...MatcherWeight) (Matcher, error) {
+	if len(weights) == 0 {
+		return Levenshtein{}, nil
+	}
+
+	combination := make(Weighted, 0, len(weights))
+	for _, w := range weights {
+		matcher, exists := matchers[w.Name]
+		if !exists {
+			return nil, fmt.Errorf("Error building matcher: unknown algorithm %q", w.Name)
+		}
+		if w.Weight <= 0 {
+			return nil, fmt.Errorf("Error building matcher: weight of %q must be positive", w.Name)
+		}
+		combination = append(combination, WeightedMatcher{matcher, w.Weight})
+	}
+
+	if len(combination) == 1 {
+		return combination[0].Matcher, nil
+	}
+	return combination, nil
+}
+
+// Levenshtein scores a guess by the number of edits needed to reach the
+// reference.
+type Levenshtein struct{}
+
+func (Levenshtein) Score(guess, reference string) float32 {
+	if guess == reference {
+		return 100
+	}
+	return GetScore(guess, reference)
+}
+
+// JaroWinkler favors guesses sharing their first characters with the
+// reference, which suits players typing the beginning of a name.
+type JaroWinkler struct{}
+
+func (JaroWinkler) Score(guess, reference string) float32 {
+	a, b := []rune(guess), []rune(reference)
+	if len(a) == 0 && len(b) == 0 {
+		return 100
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	// Characters only match within this distance of each other
+	window := max(max(len(a), len(b))/2-1, 0)
+
+	aMatched := make([]bool, len(a))
+	bMatched := make([]bool, len(b))
+	matches := 0
+	for i := range a {
+		for j := max(0, i-window); j < min(len(b), i+window+1); j++ {
+			if !bMatched[j] && a[i] == b[j] {
+				aMatched[i], bMatched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// Count matching characters appearing in a different order
+	transpositions := 0
+	j := 0
+	for i := range a {
+		if !aMatched[i] {
+			continue
+		}
+		for !bMatched[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(len(a), len(b), 4) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	return float32(100 * (jaro + float64(prefix)*0.1*(1-jaro)))
+}
+
+// TokenSet ignores the order of words and scores the words in common against
+// the remaining ones, so "world hello" matches "hello world".
+type TokenSet struct{}
+
+func (TokenSet) Score(guess, reference string) float32 {
+	guessTokens := tokenSet(guess)
+	referenceTokens := tokenSet(reference)
+
+	var common, guessOnly, referenceOnly []string
+	for _, token := range guessTokens {
+		if _, found := slices.BinarySearch(referenceTokens, token); found {
+			common = append(common, token)
+		} else {
+			guessOnly = append(guessOnly, token)
+		}
+	}
+	for _, token := range referenceTokens {
+		if _, found := slices.BinarySearch(guessTokens, token); !found {
+			referenceOnly = append(referenceOnly, token)
+		}
+	}
+
+	intersection := strings.Join(common, " ")
+	withGuess := strings.TrimSpace(intersection + " " + strings.Join(guessOnly, " "))
+	withReference := strings.TrimSpace(intersection + " " + strings.Join(referenceOnly, " "))
+
+	score := GetScore(withGuess, withReference)
+	if intersection != "" {
+		score = max(score, GetScore(intersection, withGuess), GetScore(intersection, withReference))
+	}
+	return score
+}
+
+// tokenSet returns the sorted distinct words of s
+func tokenSet(s string) []string {
+	tokens := strings.Fields(s)
+	slices.Sort(tokens)
+	return slices.Compact(tokens)
+}
+
+// Phonetic scores guesses by how they sound, so misspelled but well
+// pronounced names are accepted. Words are compared through their Double
+// Metaphone codes and their French Soundex, keeping the best of both. Words
+// without a sound of their own are compared as written.
+type Phonetic struct{}
+
+func (Phonetic) Score(guess, reference string) float32 {
+	guessCodes, guessLiterals := phoneticCodes(guess)
+	referenceCodes, referenceLiterals := phoneticCodes(reference)
+
+	var score float32
+	for i := range guessCodes {
+		score = max(score, GetScore(guessCodes[i], referenceCodes[i]))
+	}
+	if guessLiterals != "" || referenceLiterals != "" {
+		score = min(score, GetScore(guessLiterals, referenceLiterals))
+	}
+	return score
+}
+
+// phoneticCodes returns the primary and secondary Double Metaphone codes and
+// the French Soundex of the words of s, and apart the words whose code tells
+// nothing: numbers, other scripts and lone vowels all sounding the same
+func phoneticCodes(s string) ([3]string, string) {
+	var codes [3][]string
+	var literals []string
+	for _, word := range strings.Fields(s) {
+		primary, secondary := DoubleMetaphone(word)
+		if primary == "" || primary == "A" {
+			literals = append(literals, word)
+			continue
+		}
+		codes[0] = append(codes[0], primary)
+		codes[1] = append(codes[1], secondary)
+		codes[2] = append(codes[2], FrenchSoundex(word))
+	}
+	return [3]string{strings.Join(codes[0], " "), strings.Join(codes[1], " "), strings.Join(codes[2], " ")}, strings.Join(literals, " ")
+}
+
+type WeightedMatcher struct {
+	Matcher Matcher
+	Weight  float32
+}
+
+// Weighted averages the scores of several matchers.
+type Weighted []WeightedMatcher
+
+func (w Weighted) Score(guess, reference string) float32 {
+	var score, total float32
+	for _, m := range w {
+		score += m.Weight * m.Matcher.Score(guess, reference)
+		total += m.Weight
+	}
+	if total == 0 {
+		return 0
+	}
+	return score / total
+}
@@ -0,0 +1,220 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+// Labelled guesses, normalized, and whether players expect them to be
+// accepted
+var matcherCorpus = []struct {
+	guess, reference string
+	match            bool
+}{
+	// Exact and near exact answers
+	{"bohemian rhapsody", "bohemian rhapsody", true},
+	{"bohemian rapsody", "bohemian rhapsody", true},
+	{"bohemain rhapsody", "bohemian rhapsody", true},
+	{"stairway to heaven", "stairway to heaven", true},
+	{"stairway to heven", "stairway to heaven", true},
+	{"queen", "queen", true},
+	{"quen", "queen", true},
+	{"beyonce", "beyonce", true},
+	{"beyonse", "beyonce", true},
+	{"indochine", "indochine", true},
+	{"indochinne", "indochine", true},
+	{"aya nakamura", "aya nakamura", true},
+	{"aya nakamoura", "aya nakamura", true},
+	{"daft punk", "daft punk", true},
+	{"daft punck", "daft punk", true},
+	{"get lucky", "get lucky", true},
+	{"get luky", "get lucky", true},
+	{"wonderwall", "wonderwall", true},
+	{"wonderwal", "wonderwall", true},
+	{"la vie en rose", "la vie en rose", true},
+	{"la vie en rosse", "la vie en rose", true},
+	{"stromae", "stromae", true},
+	{"stromay", "stromae", true},
+	{"alors on danse", "alors on danse", true},
+	{"alor on dance", "alors on danse", true},
+	{"michael jackson", "michael jackson", true},
+	{"mickael jakson", "michael jackson", true},
+	{"jean jacques goldman", "jean jacques goldman", true},
+	{"jean jacque goldmann", "jean jacques goldman", true},
+	{"celine dion", "celine dion", true},
+	{"seline dion", "celine dion", true},
+	{"rihanna", "rihanna", true},
+	{"rihana", "rihanna", true},
+
+	// Wrong answers
+	{"queen", "the beatles", false},
+	{"hello", "yellow", false},
+	{"hello", "halo", false},
+	{"stromae", "sting", false},
+	{"daft punk", "justice", false},
+	{"wonderwall", "wonderland", false},
+	{"get lucky", "lose yourself", false},
+	{"beyonce", "britney spears", false},
+	{"indochine", "noir desir", false},
+	{"alors on danse", "papaoutai", false},
+	{"la vie en rose", "non je ne regrette rien", false},
+	{"michael jackson", "janet jackson", false},
+	{"celine dion", "dion", false},
+	{"love", "love me do", false},
+	{"rose", "roses", false},
+	{"one", "nine", false},
+	{"adele", "abba", false},
+	{"muse", "mika", false},
+	{"2", "5", false},
+	{"song 2", "song 5", false},
+	{"кино", "група", false},
+	{"a", "o", false},
+}
+
+// Validity threshold used by default in rooms
+const corpusThreshold = 80
+
+// accuracy returns the share of the corpus labelled right by the matcher
+func accuracy(m Matcher) float64 {
+	right := 0
+	for _, tc := range matcherCorpus {
+		if (m.Score(tc.guess, tc.reference) >= corpusThreshold) == tc.match {
+			right++
+		}
+	}
+	return float64(right) / float64(len(matcherCorpus))
+}
+
+func TestMatchersCorpus(t *testing.T) {
+	testcases := []struct {
+		name    string
+		weights []MatcherWeight
+		want    float64
+	}{
+		{LevenshteinMatcher, []MatcherWeight{{LevenshteinMatcher, 1}}, 0.9},
+		{JaroWinklerMatcher, []MatcherWeight{{JaroWinklerMatcher, 1}}, 0.85},
+		{TokenSetMatcher, []MatcherWeight{{TokenSetMatcher, 1}}, 0.9},
+		{PhoneticMatcher, []MatcherWeight{{PhoneticMatcher, 1}}, 0.9},
+		{"weighted", []MatcherWeight{{LevenshteinMatcher, 2}, {PhoneticMatcher, 1}}, 0.95},
+	}
+
+	for _, tc := range testcases {
+		matcher, err := NewMatcher(tc.weights...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := accuracy(matcher)
+		t.Logf("%s: %.0f%% accuracy", tc.name, 100*got)
+		if got < tc.want {
+			t.Errorf("%s accuracy = %.2f; want at least %.2f", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMatchersScore(t *testing.T) {
+	testcases := []struct {
+		matcher          Matcher
+		guess, reference string
+		want             float32
+	}{
+		{Levenshtein{}, "hello", "hello", 100},
+		{Levenshtein{}, "hello", "hallo", 80},
+		{Levenshtein{}, "", "", 100},
+		{JaroWinkler{}, "martha", "marhta", 96.1},
+		{JaroWinkler{}, "dwayne", "duane", 84},
+		{JaroWinkler{}, "abc", "xyz", 0},
+		{TokenSet{}, "world hello", "hello world", 100},
+		{TokenSet{}, "hello hello world", "hello world", 100},
+		{TokenSet{}, "hello", "bonjour", 14.3},
+		{Phonetic{}, "nite", "night", 100},
+		{Phonetic{}, "seline dion", "celine dion", 100},
+		{Phonetic{}, "song 2", "song 5", 0},
+		{Phonetic{}, "song 2", "song 2", 100},
+		{Phonetic{}, "кино", "група", 0},
+		{Phonetic{}, "a", "o", 0},
+		{Weighted{{Levenshtein{}, 1}, {TokenSet{}, 1}}, "world hello", "hello world", 63.6},
+	}
+
+	for _, tc := range testcases {
+		score := tc.matcher.Score(tc.guess, tc.reference)
+		if math.Abs(float64(score-tc.want)) > 0.1 {
+			t.Errorf("%T.Score(%q, %q) = %.1f; want %.1f", tc.matcher, tc.guess, tc.reference, score, tc.want)
+		}
+	}
+}
+
+func TestNewMatcher(t *testing.T) {
+	if m, err := NewMatcher(); err != nil || m != (Levenshtein{}) {
+		t.Errorf("NewMatcher() = %v, %v; want Levenshtein", m, err)
+	}
+	if _, err := NewMatcher(MatcherWeight{"unknown", 1}); err == nil {
+		t.Error("NewMatcher should reject unknown algorithms")
+	}
+	if _, err := NewMatcher(MatcherWeight{LevenshteinMatcher, 0}); err == nil {
+		t.Error("NewMatcher should reject non positive weights")
+	}
+}
+
+func TestDoubleMetaphone(t *testing.T) {
+	testcases := []struct {
+		in, primary, secondary string
+	}{
+		{"smith", "SM0", "XMT"},
+		{"schmidt", "XMT", "SMT"},
+		{"knight", "NT", "NT"},
+		{"caesar", "SSR", "SSR"},
+		{"jose", "HS", "HS"},
+		{"xavier", "SF", "SFR"},
+		{"laugh", "LF", "LF"},
+		{"michael", "MKL", "MXL"},
+		{"filipowicz", "FLPTS", "FLPFX"},
+	}
+
+	for _, tc := range testcases {
+		primary, secondary := DoubleMetaphone(tc.in)
+		if primary != tc.primary || secondary != tc.secondary {
+			t.Errorf("DoubleMetaphone(%q) = %q, %q; want %q, %q", tc.in, primary, secondary, tc.primary, tc.secondary)
+		}
+	}
+}
+
+func TestFrenchSoundex(t *testing.T) {
+	testcases := []struct {
+		in, want string
+	}{
+		{"piaf", "P900"},
+		{"celine", "C450"},
+		{"seline", "S450"},
+		{"goldman", "G435"},
+		{"", ""},
+	}
+
+	for _, tc := range testcases {
+		if code := FrenchSoundex(tc.in); code != tc.want {
+			t.Errorf("FrenchSoundex(%q) = %q; want %q", tc.in, code, tc.want)
+		}
+	}
+}
+
+func BenchmarkMatchers(b *testing.B) {
+	benchmarks := []struct {
+		name    string
+		matcher Matcher
+	}{
+		{LevenshteinMatcher, Levenshtein{}},
+		{JaroWinklerMatcher, JaroWinkler{}},
+		{TokenSetMatcher, TokenSet{}},
+		{PhoneticMatcher, Phonetic{}},
+		{"weighted", Weighted{{Levenshtein{}, 2}, {Phonetic{}, 1}}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, tc := range matcherCorpus {
+					bm.matcher.Score(tc.guess, tc.reference)
+				}
+			}
+		})
+	}
+}
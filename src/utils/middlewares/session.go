@@ -10,11 +10,21 @@ import (
 	"lcor.io/songs/src/services"
 )
 
-// Store sessions in memory for now
-var store = session.New(session.Config{
+var sessionConfig = session.Config{
 	Expiration: time.Hour * 24 * 30,
 	KeyLookup:  "cookie:songs_session",
-})
+}
+
+// Store sessions in memory until a persistent storage is set
+var store = session.New(sessionConfig)
+
+// SetSessionStorage persists sessions in the given storage, so players keep
+// their identity across restarts.
+func SetSessionStorage(storage fiber.Storage) {
+	config := sessionConfig
+	config.Storage = storage
+	store = session.New(config)
+}
 
 func SessionMiddleware(c fiber.Ctx) error {
 	session, err := store.Get(c)
@@ -28,7 +38,7 @@ func SessionMiddleware(c fiber.Ctx) error {
 	// TODO: Until proper authentication, users are automatically created while
 	// navigating the site
 	if userId == nil || !services.UserExists(userId.(string)) {
-		nbUsers := len(services.GetUsers())
+		nbUsers := services.CountUsers()
 		newUser := services.CreateUser("Anonymous-" + fmt.Sprintf("%d", nbUsers+1))
 		session.Set("user_id", newUser.ID)
 	}
@@ -0,0 +1,493 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DoubleMetaphone encodes a word by its English pronunciation, following
+// Lawrence Philips' algorithm. It returns a primary code and a secondary one
+// for alternate pronunciations, mostly of foreign names. The word is expected
+// to be normalized, without diacritics.
+func DoubleMetaphone(word string) (string, string) {
+	word = strings.ToUpper(word)
+	m := metaphone{word: []rune(word)}
+	m.length = len(m.word)
+	m.last = m.length - 1
+	m.slavoGermanic = strings.ContainsAny(word, "WK") || strings.Contains(word, "CZ") || strings.Contains(word, "WITZ")
+	m.encode()
+	return m.primary.String(), m.secondary.String()
+}
+
+type metaphone struct {
+	word               []rune
+	length, last       int
+	slavoGermanic      bool
+	primary, secondary strings.Builder
+}
+
+func (m *metaphone) at(i int) rune {
+	if i < 0 || i >= m.length {
+		return 0
+	}
+	return m.word[i]
+}
+
+// is reports whether the length runes starting at start are one of options
+func (m *metaphone) is(start, length int, options ...string) bool {
+	if start < 0 || start+length > m.length {
+		return false
+	}
+	for _, option := range options {
+		if matchRunes(m.word[start:start+length], option) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRunes(runes []rune, s string) bool {
+	i := 0
+	for _, r := range s {
+		if i >= len(runes) || runes[i] != r {
+			return false
+		}
+		i++
+	}
+	return i == len(runes)
+}
+
+func (m *metaphone) vowel(i int) bool {
+	return strings.ContainsRune("AEIOUY", m.at(i))
+}
+
+func (m *metaphone) add(codes ...string) {
+	m.primary.WriteString(codes[0])
+	if len(codes) > 1 {
+		m.secondary.WriteString(codes[1])
+	} else {
+		m.secondary.WriteString(codes[0])
+	}
+}
+
+// skip returns the number of runes to advance, 2 if the next rune is one of
+// doubles, 1 otherwise
+func (m *metaphone) skip(current int, doubles string) int {
+	if strings.ContainsRune(doubles, m.at(current+1)) {
+		return 2
+	}
+	return 1
+}
+
+func (m *metaphone) encode() {
+	current := 0
+
+	// Skip silent letters at the start of the word
+	if m.is(0, 2, "GN", "KN", "PN", "WR", "PS") {
+		current++
+	}
+	if m.at(0) == 'X' {
+		m.add("S")
+		current++
+	}
+
+	for current < m.length {
+		switch m.at(current) {
+		case 'A', 'E', 'I', 'O', 'U', 'Y':
+			if current == 0 {
+				m.add("A")
+			}
+			current++
+		case 'B':
+			m.add("P")
+			current += m.skip(current, "B")
+		case 'C':
+			current += m.encodeC(current)
+		case 'D':
+			switch {
+			case m.is(current, 2, "DG") && m.is(current+2, 1, "I", "E", "Y"):
+				m.add("J")
+				current += 3
+			case m.is(current, 2, "DG"):
+				m.add("TK")
+				current += 2
+			case m.is(current, 2, "DT", "DD"):
+				m.add("T")
+				current += 2
+			default:
+				m.add("T")
+				current++
+			}
+		case 'F':
+			m.add("F")
+			current += m.skip(current, "F")
+		case 'G':
+			current += m.encodeG(current)
+		case 'H':
+			if (current == 0 || m.vowel(current-1)) && m.vowel(current+1) {
+				m.add("H")
+				current += 2
+			} else {
+				current++
+			}
+		case 'J':
+			current += m.encodeJ(current)
+		case 'K':
+			m.add("K")
+			current += m.skip(current, "K")
+		case 'L':
+			if m.at(current+1) == 'L' {
+				// Spanish "ll" is silent in the alternate pronunciation
+				if (current == m.length-3 && m.is(current-1, 4, "ILLO", "ILLA", "ALLE")) ||
+					((m.is(m.last-1, 2, "AS", "OS") || m.is(m.last, 1, "A", "O")) && m.is(current-1, 4, "ALLE")) {
+					m.add("L", "")
+				} else {
+					m.add("L")
+				}
+				current += 2
+			} else {
+				m.add("L")
+				current++
+			}
+		case 'M':
+			m.add("M")
+			if (m.is(current-1, 3, "UMB") && (current+1 == m.last || m.is(current+2, 2, "ER"))) || m.at(current+1) == 'M' {
+				current += 2
+			} else {
+				current++
+			}
+		case 'N':
+			m.add("N")
+			current += m.skip(current, "N")
+		case 'P':
+			if m.at(current+1) == 'H' {
+				m.add("F")
+				current += 2
+			} else {
+				m.add("P")
+				current += m.skip(current, "PB")
+			}
+		case 'Q':
+			m.add("K")
+			current += m.skip(current, "Q")
+		case 'R':
+			// French final "ier" is silent in the alternate pronunciation
+			if current == m.last && !m.slavoGermanic && m.is(current-2, 2, "IE") && !m.is(current-4, 2, "ME", "MA") {
+				m.add("", "R")
+			} else {
+				m.add("R")
+			}
+			current += m.skip(current, "R")
+		case 'S':
+			current += m.encodeS(current)
+		case 'T':
+			switch {
+			case m.is(current, 4, "TION"), m.is(current, 3, "TIA", "TCH"):
+				m.add("X")
+				current += 3
+			case m.is(current, 2, "TH"), m.is(current, 3, "TTH"):
+				if m.is(current+2, 2, "OM", "AM") || m.is(0, 3, "SCH") {
+					m.add("T")
+				} else {
+					m.add("0", "T")
+				}
+				current += 2
+			default:
+				m.add("T")
+				current += m.skip(current, "TD")
+			}
+		case 'V':
+			m.add("F")
+			current += m.skip(current, "V")
+		case 'W':
+			current += m.encodeW(current)
+		case 'X':
+			// French final "eau", "aux" and "oux" are silent
+			if !(current == m.last && (m.is(current-3, 3, "IAU", "EAU") || m.is(current-2, 2, "AU", "OU"))) {
+				m.add("KS")
+			}
+			current += m.skip(current, "CX")
+		case 'Z':
+			switch {
+			case m.at(current+1) == 'H':
+				m.add("J")
+				current += 2
+			case m.is(current+1, 2, "ZO", "ZI", "ZA"), m.slavoGermanic && current > 0 && m.at(current-1) != 'T':
+				m.add("S", "TS")
+				current += m.skip(current, "Z")
+			default:
+				m.add("S")
+				current += m.skip(current, "Z")
+			}
+		default:
+			current++
+		}
+	}
+}
+
+func (m *metaphone) encodeC(current int) int {
+	switch {
+	// Germanic "ach", as in "bacher" or "macher"
+	case current > 1 && !m.vowel(current-2) && m.is(current-1, 3, "ACH") &&
+		m.at(current+2) != 'I' && (m.at(current+2) != 'E' || m.is(current-2, 6, "BACHER", "MACHER")):
+		m.add("K")
+		return 2
+	case current == 0 && m.is(current, 6, "CAESAR"):
+		m.add("S")
+		return 2
+	case m.is(current, 4, "CHIA"):
+		m.add("K")
+		return 2
+	case m.is(current, 2, "CH"):
+		switch {
+		case current > 0 && m.is(current, 4, "CHAE"):
+			m.add("K", "X")
+		// Greek roots, as in "chorus" or "character"
+		case current == 0 && (m.is(current+1, 5, "HARAC", "HARIS") || m.is(current+1, 3, "HOR", "HYM", "HIA", "HEM")) && !m.is(0, 5, "CHORE"):
+			m.add("K")
+		case m.is(0, 3, "SCH") || m.is(current-2, 6, "ORCHES", "ARCHIT", "ORCHID") || m.is(current+2, 1, "T", "S") ||
+			((m.is(current-1, 1, "A", "O", "U", "E") || current == 0) && (m.is(current+2, 1, "L", "R", "N", "M", "B", "H", "F", "V", "W") || current+2 >= m.length)):
+			m.add("K")
+		case current > 0 && m.is(0, 2, "MC"):
+			m.add("K")
+		case current > 0:
+			m.add("X", "K")
+		default:
+			m.add("X")
+		}
+		return 2
+	case m.is(current, 2, "CZ") && !m.is(current-2, 4, "WICZ"):
+		m.add("S", "X")
+		return 2
+	case m.is(current+1, 3, "CIA"):
+		m.add("X")
+		return 3
+	case m.is(current, 2, "CC") && !(current == 1 && m.at(0) == 'M'):
+		if m.is(current+2, 1, "I", "E", "H") && !m.is(current+2, 2, "HU") {
+			if (current == 1 && m.at(current-1) == 'A') || m.is(current-1, 5, "UCCEE", "UCCES") {
+				m.add("KS")
+			} else {
+				m.add("X")
+			}
+			return 3
+		}
+		m.add("K")
+		return 2
+	case m.is(current, 2, "CK", "CG", "CQ"):
+		m.add("K")
+		return 2
+	case m.is(current, 2, "CI", "CE", "CY"):
+		if m.is(current, 3, "CIO", "CIE", "CIA") {
+			m.add("S", "X")
+		} else {
+			m.add("S")
+		}
+		return 2
+	}
+
+	m.add("K")
+	if m.is(current+1, 1, "C", "K", "Q") && !m.is(current+1, 2, "CE", "CI") {
+		return 2
+	}
+	return 1
+}
+
+func (m *metaphone) encodeG(current int) int {
+	if m.at(current+1) == 'H' {
+		switch {
+		case current > 0 && !m.vowel(current-1):
+			m.add("K")
+		case current == 0 && m.at(current+2) == 'I':
+			m.add("J")
+		case current == 0:
+			m.add("K")
+		// Silent "gh", as in "hugh" or "bough"
+		case (current > 1 && m.is(current-2, 1, "B", "H", "D")) ||
+			(current > 2 && m.is(current-3, 1, "B", "H", "D")) ||
+			(current > 3 && m.is(current-4, 1, "B", "H")):
+		// "gh" sounding like "f", as in "laugh" or "tough"
+		case current > 2 && m.at(current-1) == 'U' && m.is(current-3, 1, "C", "G", "L", "R", "T"):
+			m.add("F")
+		case current > 0 && m.at(current-1) != 'I':
+			m.add("K")
+		}
+		return 2
+	}
+
+	switch {
+	case m.at(current+1) == 'N':
+		switch {
+		case current == 1 && m.vowel(0) && !m.slavoGermanic:
+			m.add("KN", "N")
+		case !m.is(current+2, 2, "EY") && m.at(current+1) != 'Y' && !m.slavoGermanic:
+			m.add("N", "KN")
+		default:
+			m.add("KN")
+		}
+		return 2
+	case m.is(current+1, 2, "LI") && !m.slavoGermanic:
+		m.add("KL", "L")
+		return 2
+	case current == 0 && (m.at(current+1) == 'Y' || m.is(current+1, 2, "ES", "EP", "EB", "EL", "EY", "IB", "IL", "IN", "IE", "EI", "ER")):
+		m.add("K", "J")
+		return 2
+	case (m.is(current+1, 2, "ER") || m.at(current+1) == 'Y') && !m.is(0, 6, "DANGER", "RANGER", "MANGER") &&
+		!m.is(current-1, 1, "E", "I") && !m.is(current-1, 3, "RGY", "OGY"):
+		m.add("K", "J")
+		return 2
+	case m.is(current+1, 1, "E", "I", "Y") || m.is(current-1, 4, "AGGI", "OGGI"):
+		switch {
+		case m.is(0, 3, "SCH") || m.is(current+1, 2, "ET"):
+			m.add("K")
+		case m.is(current+1, 3, "IER") && current+3 == m.length:
+			m.add("J")
+		default:
+			m.add("J", "K")
+		}
+		return 2
+	}
+
+	m.add("K")
+	return m.skip(current, "G")
+}
+
+func (m *metaphone) encodeJ(current int) int {
+	if m.is(current, 4, "JOSE") {
+		if current == 0 && current+4 == m.length {
+			m.add("H")
+		} else {
+			m.add("J", "H")
+		}
+		return 1
+	}
+
+	switch {
+	case current == 0:
+		m.add("J", "A")
+	case m.vowel(current-1) && !m.slavoGermanic && (m.at(current+1) == 'A' || m.at(current+1) == 'O'):
+		m.add("J", "H")
+	case current == m.last:
+		m.add("J", "")
+	case !m.is(current+1, 1, "L", "T", "K", "S", "N", "M", "B", "Z") && !m.is(current-1, 1, "S", "K", "L"):
+		m.add("J")
+	}
+	return m.skip(current, "J")
+}
+
+func (m *metaphone) encodeS(current int) int {
+	switch {
+	// Silent "s", as in "island" or "carlysle"
+	case m.is(current-1, 3, "ISL", "YSL"):
+		return 1
+	case current == 0 && m.is(current, 5, "SUGAR"):
+		m.add("X", "S")
+		return 1
+	case m.is(current, 2, "SH"):
+		if m.is(current+1, 4, "HEIM", "HOEK", "HOLM", "HOLZ") {
+			m.add("S")
+		} else {
+			m.add("X")
+		}
+		return 2
+	case m.is(current, 3, "SIO", "SIA") || m.is(current, 4, "SIAN"):
+		if m.slavoGermanic {
+			m.add("S")
+		} else {
+			m.add("S", "X")
+		}
+		return 3
+	case (current == 0 && m.is(current+1, 1, "M", "N", "L", "W")) || m.at(current+1) == 'Z':
+		m.add("S", "X")
+		return m.skip(current, "Z")
+	case m.is(current, 2, "SC"):
+		switch {
+		case m.at(current+2) == 'H' && m.is(current+3, 2, "OO", "ER", "EN", "UY", "ED", "EM"):
+			if m.is(current+3, 2, "ER", "EN") {
+				m.add("X", "SK")
+			} else {
+				m.add("SK")
+			}
+		case m.at(current+2) == 'H' && current == 0 && !m.vowel(3) && m.at(3) != 'W':
+			m.add("X", "S")
+		case m.at(current+2) == 'H':
+			m.add("X")
+		case m.is(current+2, 1, "I", "E", "Y"):
+			m.add("S")
+		default:
+			m.add("SK")
+		}
+		return 3
+	}
+
+	// French final "ais" and "ois" are silent
+	if current == m.last && m.is(current-2, 2, "AI", "OI") {
+		m.add("", "S")
+	} else {
+		m.add("S")
+	}
+	return m.skip(current, "SZ")
+}
+
+func (m *metaphone) encodeW(current int) int {
+	if m.is(current, 2, "WR") {
+		m.add("R")
+		return 2
+	}
+
+	if current == 0 && m.vowel(current+1) {
+		m.add("A", "F")
+	} else if current == 0 && m.at(current+1) == 'H' {
+		m.add("A")
+	}
+
+	switch {
+	// Polish "w" sounds like "f", as in "filipowicz"
+	case (current == m.last && m.vowel(current-1)) || m.is(current-1, 5, "EWSKI", "EWSKY", "OWSKI", "OWSKY") || m.is(0, 3, "SCH"):
+		m.add("", "F")
+		return 1
+	case m.is(current, 4, "WICZ", "WITZ"):
+		m.add("TS", "FX")
+		return 4
+	}
+	return 1
+}
+
+// Soundex digits of the consonants, adapted to French pronunciation
+var frenchSoundexCodes = map[rune]byte{
+	'B': '1', 'P': '1',
+	'C': '2', 'K': '2', 'Q': '2',
+	'D': '3', 'T': '3',
+	'L': '4',
+	'M': '5', 'N': '5',
+	'R': '6',
+	'G': '7', 'J': '7',
+	'S': '8', 'X': '8', 'Z': '8',
+	'F': '9', 'V': '9',
+}
+
+// FrenchSoundex encodes a word as its first letter followed by three digits
+// grouping consonants that sound alike in French. Vowels and mute letters are
+// ignored.
+func FrenchSoundex(word string) string {
+	code := make([]byte, 0, 4)
+	var previous byte
+	for _, r := range strings.ToUpper(word) {
+		if !unicode.IsLetter(r) || r > unicode.MaxASCII {
+			continue
+		}
+		digit := frenchSoundexCodes[r]
+		if len(code) == 0 {
+			code = append(code, byte(r))
+		} else if digit != 0 && digit != previous {
+			code = append(code, digit)
+		}
+		if len(code) == 4 {
+			break
+		}
+		previous = digit
+	}
+	for len(code) > 0 && len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Romanizations of the Cyrillic letters of Russian, Ukrainian, Belarusian and
+// Serbian
+var cyrillic = map[string]string{
+	"а": "a", "б": "b", "в": "v", "г": "g", "д": "d", "е": "e", "ё": "yo",
+	"ж": "zh", "з": "z", "и": "i", "й": "y", "к": "k", "л": "l", "м": "m",
+	"н": "n", "о": "o", "п": "p", "р": "r", "с": "s", "т": "t", "у": "u",
+	"ф": "f", "х": "kh", "ц": "ts", "ч": "ch", "ш": "sh", "щ": "shch", "ъ": "",
+	"ы": "y", "ь": "", "э": "e", "ю": "yu", "я": "ya",
+	"і": "i", "ї": "yi", "є": "ye", "ґ": "g", "ў": "u",
+	"ђ": "dj", "ј": "j", "љ": "lj", "њ": "nj", "ћ": "c", "џ": "dz",
+}
+
+// Romanizations of Greek letters and diphthongs
+var greek = map[string]string{
+	"α": "a", "β": "v", "γ": "g", "δ": "d", "ε": "e", "ζ": "z", "η": "i",
+	"θ": "th", "ι": "i", "κ": "k", "λ": "l", "μ": "m", "ν": "n", "ξ": "x",
+	"ο": "o", "π": "p", "ρ": "r", "σ": "s", "ς": "s", "τ": "t", "υ": "y",
+	"φ": "f", "χ": "ch", "ψ": "ps", "ω": "o",
+	"ά": "a", "έ": "e", "ή": "i", "ί": "i", "ό": "o", "ύ": "y", "ώ": "o",
+	"ϊ": "i", "ϋ": "y", "ΐ": "i", "ΰ": "y",
+	"ου": "ou", "ού": "ou", "αυ": "av", "αύ": "av", "ευ": "ev", "εύ": "ev",
+}
+
+// Hepburn romanizations of hiragana. Katakana are converted to hiragana
+// first, and contracted sounds like "きゃ" are derived from these.
+var kana = map[string]string{
+	"あ": "a", "い": "i", "う": "u", "え": "e", "お": "o",
+	"か": "ka", "き": "ki", "く": "ku", "け": "ke", "こ": "ko",
+	"さ": "sa", "し": "shi", "す": "su", "せ": "se", "そ": "so",
+	"た": "ta", "ち": "chi", "つ": "tsu", "て": "te", "と": "to",
+	"な": "na", "に": "ni", "ぬ": "nu", "ね": "ne", "の": "no",
+	"は": "ha", "ひ": "hi", "ふ": "fu", "へ": "he", "ほ": "ho",
+	"ま": "ma", "み": "mi", "む": "mu", "め": "me", "も": "mo",
+	"や": "ya", "ゆ": "yu", "よ": "yo",
+	"ら": "ra", "り": "ri", "る": "ru", "れ": "re", "ろ": "ro",
+	"わ": "wa", "ゐ": "i", "ゑ": "e", "を": "o", "ん": "n",
+	"が": "ga", "ぎ": "gi", "ぐ": "gu", "げ": "ge", "ご": "go",
+	"ざ": "za", "じ": "ji", "ず": "zu", "ぜ": "ze", "ぞ": "zo",
+	"だ": "da", "ぢ": "ji", "づ": "zu", "で": "de", "ど": "do",
+	"ば": "ba", "び": "bi", "ぶ": "bu", "べ": "be", "ぼ": "bo",
+	"ぱ": "pa", "ぴ": "pi", "ぷ": "pu", "ぺ": "pe", "ぽ": "po",
+	"ゔ": "vu",
+	"ぁ": "a", "ぃ": "i", "ぅ": "u", "ぇ": "e", "ぉ": "o",
+	"ゃ": "ya", "ゅ": "yu", "ょ": "yo", "ゎ": "wa",
+
+	// Sounds of borrowed words
+	"ふぁ": "fa", "ふぃ": "fi", "ふぇ": "fe", "ふぉ": "fo",
+	"てぃ": "ti", "でぃ": "di", "とぅ": "tu", "どぅ": "du",
+	"うぃ": "wi", "うぇ": "we", "うぉ": "wo",
+	"ゔぁ": "va", "ゔぃ": "vi", "ゔぇ": "ve", "ゔぉ": "vo",
+	"しぇ": "she", "じぇ": "je", "ちぇ": "che", "つぁ": "tsa",
+}
+
+// Revised Romanization of the initial consonants, vowels and final
+// consonants composing hangul syllables
+var (
+	hangulInitials = []string{
+		"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj",
+		"ch", "k", "t", "p", "h",
+	}
+	hangulVowels = []string{
+		"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe",
+		"yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i",
+	}
+	hangulFinals = []string{
+		"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p",
+		"l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t",
+	}
+	// Final consonants carried over to a following silent initial, like in
+	// "한국어" read "hangugeo"
+	hangulLinkedFinals = map[int]string{
+		1: "g", 2: "kk", 4: "n", 7: "d", 8: "r", 16: "m", 17: "b", 19: "s",
+		20: "ss", 22: "j", 23: "ch", 24: "k", 25: "t", 26: "p", 27: "h",
+	}
+)
+
+const (
+	hangulFirst = 0xAC00
+	hangulLast  = 0xD7A3
+	// Index of the silent initial consonant
+	hangulSilentInitial = 11
+)
+
+// Every romanized sequence of runes, and the length of the longest one
+var (
+	romanizations      = map[string]string{}
+	romanizationLength = 0
+)
+
+func init() {
+	for _, table := range []map[string]string{cyrillic, greek, kana} {
+		for sequence, romanized := range table {
+			romanizations[sequence] = romanized
+			romanizationLength = max(romanizationLength, len([]rune(sequence)))
+		}
+	}
+
+	// Contracted sounds, like "きゃ" read "kya" or "しゃ" read "sha"
+	for _, syllable := range []string{"き", "し", "ち", "に", "ひ", "み", "り", "ぎ", "じ", "ぢ", "び", "ぴ"} {
+		consonant := strings.TrimSuffix(kana[syllable], "i")
+		if consonant != "sh" && consonant != "ch" && consonant != "j" {
+			consonant += "y"
+		}
+		for small, vowel := range map[string]string{"ゃ": "a", "ゅ": "u", "ょ": "o"} {
+			romanizations[syllable+small] = consonant + vowel
+		}
+	}
+}
+
+// Romanize transliterates Cyrillic, Greek, kana and hangul to Latin letters.
+// Other characters, including kanji, are kept as is. It should run before
+// Normalize, as removing diacritics alters some letters. The result is
+// lowercased.
+func Romanize(s string) string {
+	input := []rune(s)
+	for i, r := range input {
+		r = unicode.ToLower(r)
+		// Katakana are read like hiragana
+		if r >= 'ァ' && r <= 'ヶ' {
+			r -= 'ァ' - 'ぁ'
+		}
+		input[i] = r
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(input); {
+		r := input[i]
+		switch {
+		case r >= hangulFirst && r <= hangulLast:
+			b.WriteString(romanizeHangul(r, input[i+1:]))
+			i++
+			continue
+		case r == 'っ':
+			// Small tsu doubles the following consonant
+			if next, _ := romanizeAt(input, i+1); next != "" {
+				b.WriteByte(next[0])
+			}
+			i++
+			continue
+		case r == 'ー':
+			// Long vowels are written once
+			i++
+			continue
+		}
+
+		if romanized, length := romanizeAt(input, i); length > 0 {
+			b.WriteString(romanized)
+			i += length
+			continue
+		}
+		b.WriteRune(r)
+		i++
+	}
+	return b.String()
+}
+
+// RomanizedScore compares a normalized guess to a normalized romanization.
+// Romanizations split words and spell loanwords their own way, "Gangnam Style"
+// becoming "gangnamseutail", so both are compared without spaces through
+// Jaro-Winkler, which forgives the added vowels. As it favors common prefixes,
+// a guess much shorter or longer than the romanization scores nothing.
+func RomanizedScore(guess, romanized string) float32 {
+	guess, romanized = strings.ReplaceAll(guess, " ", ""), strings.ReplaceAll(romanized, " ", "")
+	guessLength, romanizedLength := len([]rune(guess)), len([]rune(romanized))
+	if 4*guessLength < 3*romanizedLength || 4*romanizedLength < 3*guessLength {
+		return 0
+	}
+	return JaroWinkler{}.Score(guess, romanized)
+}
+
+// romanizeAt romanizes the longest known sequence starting at i, returning
+// its length in runes
+func romanizeAt(input []rune, i int) (string, int) {
+	for length := min(romanizationLength, len(input)-i); length > 0; length-- {
+		if romanized, exists := romanizations[string(input[i:i+length])]; exists {
+			return romanized, length
+		}
+	}
+	return "", 0
+}
+
+func romanizeHangul(syllable rune, next []rune) string {
+	index := int(syllable - hangulFirst)
+	initial, vowel, final := index/588, (index%588)/28, index%28
+
+	finalRomanized := hangulFinals[final]
+	if len(next) > 0 && next[0] >= hangulFirst && next[0] <= hangulLast && int(next[0]-hangulFirst)/588 == hangulSilentInitial {
+		if linked, exists := hangulLinkedFinals[final]; exists {
+			finalRomanized = linked
+		}
+	}
+
+	return hangulInitials[initial] + hangulVowels[vowel] + finalRomanized
+}
@@ -0,0 +1,53 @@
+package utils
+
+import "testing"
+
+func TestRomanize(t *testing.T) {
+	testcases := []struct {
+		in, want string
+	}{
+		{"Hello World", "hello world"},
+		{"Кино", "kino"},
+		{"Мумий Тролль", "mumiy troll"},
+		{"Щедрик", "shchedrik"},
+		{"Ελλάδα", "ellada"},
+		{"Μουσική", "mousiki"},
+		{"Ευτυχία", "evtychia"},
+		{"さくら", "sakura"},
+		{"ラーメン", "ramen"},
+		{"ちょっと", "chotto"},
+		{"きゃりーぱみゅぱみゅ", "kyaripamyupamyu"},
+		{"ファイト", "faito"},
+		{"강남스타일", "gangnamseutail"},
+		{"한국어", "hangugeo"},
+		{"방탄소년단", "bangtansonyeondan"},
+		{"東京", "東京"},
+		{"Кино - 2024", "kino - 2024"},
+	}
+
+	for _, tc := range testcases {
+		if romanized := Romanize(tc.in); romanized != tc.want {
+			t.Errorf("Romanize(%q) = %q; want %q", tc.in, romanized, tc.want)
+		}
+	}
+}
+
+func TestRomanizedScore(t *testing.T) {
+	testcases := []struct {
+		guess, name string
+		valid       bool
+	}{
+		{"gangnam style", "강남스타일", true},
+		{"gangnamstyle", "강남스타일", true},
+		{"gangnam", "강남스타일", false},
+		{"gruppa krovi", "Группа крови", true},
+		{"bts", "방탄소년단", false},
+	}
+
+	for _, tc := range testcases {
+		score := RomanizedScore(tc.guess, Normalize(Romanize(tc.name)))
+		if valid := score >= 80; valid != tc.valid {
+			t.Errorf("RomanizedScore(%q, %q) = %v; want valid %v", tc.guess, tc.name, score, tc.valid)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -9,9 +10,42 @@ import (
 	"golang.org/x/text/unicode/norm"
 )
 
+// Words introducing featured artists. Credits with "with" are only found
+// between brackets, which are cut anyway.
+var featuringWords = map[string]bool{"feat": true, "ft": true, "featuring": true}
+
+// Words describing a version of a track rather than its title
+var versionWords = map[string]bool{
+	"remaster": true, "remastered": true, "live": true, "radio": true, "edit": true,
+	"version": true, "remix": true, "mix": true, "mono": true, "stereo": true,
+	"edition": true, "deluxe": true, "single": true, "extended": true, "demo": true,
+	"instrumental": true, "acoustic": true, "unplugged": true,
+}
+
+var articles = map[string]bool{"the": true, "le": true, "la": true, "les": true, "el": true}
+
+// Separators after which a name may only describe its version, like
+// "Song / Live", when not cut already
+var versionSeparators = []string{": ", " / ", " | ", " – ", " — "}
+
+// Numbers written as words, in English and French
+var numberWords = map[string]int{
+	"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+	"thirteen": 13, "fourteen": 14, "fifteen": 15, "sixteen": 16, "seventeen": 17,
+	"eighteen": 18, "nineteen": 19, "twenty": 20, "thirty": 30, "forty": 40,
+	"fifty": 50, "sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90, "hundred": 100,
+
+	"deux": 2, "trois": 3, "quatre": 4, "cinq": 5, "sept": 7, "huit": 8,
+	"neuf": 9, "dix": 10, "onze": 11, "douze": 12, "treize": 13, "quatorze": 14,
+	"quinze": 15, "seize": 16, "vingt": 20, "trente": 30, "quarante": 40,
+	"cinquante": 50, "soixante": 60, "cent": 100,
+}
+
 // Normalize sanitizes Spotigy songs names by removing diacritics, special
-// characters and lowercasing the input. It also slice the stings to remove
-// unimportant characters.
+// characters and lowercasing the input. It also slices the strings to remove
+// unimportant parts, like featured artists, versions and leading articles,
+// and writes numbers as digits.
 func Normalize(s string) string {
 	// Runs a transformer to remove all diacritics and lowercase the input
 	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC, runes.Map(unicode.ToLower))
@@ -23,20 +57,124 @@ func Normalize(s string) string {
 		normalized, _, _ = strings.Cut(normalized, r)
 	}
 
-	// Remove forbidden characters
-	normalized = strings.NewReplacer(" - ", " ", " & ", " ", ".", " ", "!", " ", "remix", " ", "/", " ", "edit", " ", "from", " ", "-", " ", "'", "").Replace(normalized)
+	words := splitWords(trimVersionSuffix(normalized))
+	words = trimFeaturing(words)
+	words = trimVersion(words)
+	words = TrimArticle(words)
+	words = wordsToNumbers(words)
+
+	return strings.Join(words, " ")
+}
+
+var droppedPunctuation = strings.NewReplacer("'", "", "’", "", ".", "")
 
-	return normalized
+// splitWords splits s on anything but letters and numbers. Apostrophes and dots
+// are dropped rather than splitting, so "don't" and "R.E.M." stay whole.
+func splitWords(s string) []string {
+	s = droppedPunctuation.Replace(s)
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.IsMark(r)
+	})
+}
+
+// trimFeaturing drops featured artists credits
+func trimFeaturing(words []string) []string {
+	for i, word := range words {
+		if i > 0 && featuringWords[word] {
+			return words[:i]
+		}
+	}
+	return words
+}
+
+// trimVersionSuffix cuts a version description following a separator, like
+// " / live"
+func trimVersionSuffix(s string) string {
+	for _, separator := range versionSeparators {
+		if i := strings.LastIndex(s, separator); i > 0 && isVersion(splitWords(s[i+len(separator):])) {
+			s = s[:i]
+		}
+	}
+	return s
 }
 
-func Permutations(input []string) []string {
-	result := make([]string, 0, len(input)*(len(input)+1)/2)
+// trimVersion drops trailing version descriptions of at least two words, like
+// "remastered 2011" or "radio edit", keeping at least one word. A lone version
+// word is part of the title, as in "Let Me Live".
+func trimVersion(words []string) []string {
+	end := len(words)
+	for end > 1 && isVersionMarker(words[end-1]) {
+		end--
+	}
+	if len(words)-end < 2 || !isVersion(words[end:]) {
+		return words
+	}
+	return words[:end]
+}
 
-	for length := 1; length <= len(input); length++ {
-		for index := 0; index <= len(input)-length; index++ {
-			result = append(result, strings.Join(input[index:index+length], " "))
+// isVersion reports whether the words only describe a version of a track
+func isVersion(words []string) bool {
+	hasVersionWord := false
+	for _, word := range words {
+		if !isVersionMarker(word) {
+			return false
 		}
+		hasVersionWord = hasVersionWord || versionWords[word]
+	}
+	return hasVersionWord
+}
+
+func isVersionMarker(word string) bool {
+	return versionWords[word] || isYear(word)
+}
+
+func isYear(word string) bool {
+	year, err := strconv.Atoi(word)
+	return err == nil && len(word) == 4 && year >= 1900 && year <= 2100
+}
+
+// TrimArticle drops the leading article of normalized words, keeping at least
+// one word, so guesses like "yesterday the beatles" can match "beatles".
+// Repeated articles, as in "La La Land", are part of the title.
+func TrimArticle(words []string) []string {
+	if len(words) > 1 && articles[words[0]] && words[1] != words[0] {
+		return words[1:]
 	}
+	return words
+}
 
+// wordsToNumbers writes numbers as digits, joining tens and units like
+// "twenty one"
+func wordsToNumbers(words []string) []string {
+	result := make([]string, 0, len(words))
+	for i := 0; i < len(words); i++ {
+		number, isNumber := numberWords[words[i]]
+		if !isNumber {
+			result = append(result, words[i])
+			continue
+		}
+		if number >= 20 && number < 100 && number%10 == 0 && i+1 < len(words) {
+			if unit := numberWords[words[i+1]]; unit > 0 && unit < 10 {
+				number += unit
+				i++
+			}
+		}
+		result = append(result, strconv.Itoa(number))
+	}
 	return result
 }
+
+// Ordinal writes a rank in English, like "1st" or "12th".
+func Ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
@@ -1,7 +1,7 @@
 package utils
 
 import (
-	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -17,6 +17,49 @@ func TestNormalize(t *testing.T) {
 		{"Hello World & Friends", "hello world friends"},
 		{"Héllò Wo̧rld - Remix", "hello world"},
 		{"Héllò - Wo̧rld - Remix", "hello"},
+
+		// Words containing version or featuring words
+		{"Edith", "edith"},
+		{"Fromage", "fromage"},
+		{"Edit", "edit"},
+		{"Featherweight", "featherweight"},
+
+		// Featured artists
+		{"Hello (feat. Someone)", "hello"},
+		{"Hello feat. Someone", "hello"},
+		{"Hello ft. Someone", "hello"},
+		{"Hello featuring Someone", "hello"},
+		{"Hello (with Someone)", "hello"},
+		{"Feat", "feat"},
+
+		// Versions
+		{"Song - Remastered 2011", "song"},
+		{"Song Remastered 2011", "song"},
+		{"Song Radio Edit", "song"},
+		{"Song - Live", "song"},
+		{"Song / Live", "song"},
+		{"Song: Acoustic Version", "song"},
+		{"Song 2011", "song 2011"},
+		{"Let Me Live", "let me live"},
+		{"I Want to Live", "i want to live"},
+		{"Live and Let Die", "live and let die"},
+		{"Remix", "remix"},
+
+		// Articles
+		{"The Scientist", "scientist"},
+		{"Le Freak", "freak"},
+		{"Les Champs-Élysées", "champs elysees"},
+		{"El Condor Pasa", "condor pasa"},
+		{"La La Land", "la la land"},
+		{"The", "the"},
+		{"Theo", "theo"},
+
+		// Numbers
+		{"Song 2", "song 2"},
+		{"Song Two", "song 2"},
+		{"Twenty One Pilots", "21 pilots"},
+		{"Quatre Saisons", "4 saisons"},
+		{"Someone", "someone"},
 	}
 
 	for _, tc := range testcases {
@@ -27,18 +70,42 @@ func TestNormalize(t *testing.T) {
 	}
 }
 
-func TestPermutations(t *testing.T) {
-	testscases := []struct {
-		in, want []string
+func TestTrimArticle(t *testing.T) {
+	testcases := []struct {
+		in, want string
 	}{
-		{[]string{"Hello", "world"}, []string{"Hello", "world", "Hello world"}},
-		{[]string{"Hello"}, []string{"Hello"}},
+		{"the beatles", "beatles"},
+		{"beatles", "beatles"},
+		{"the", "the"},
+		{"theo", "theo"},
+		{"la la land", "la la land"},
 	}
 
-	for _, tc := range testscases {
-		permutations := Permutations(tc.in)
-		if !reflect.DeepEqual(permutations, tc.want) {
-			t.Errorf("Permutations(%q) = %q; want %q", tc.in, permutations, tc.want)
+	for _, tc := range testcases {
+		if trimmed := strings.Join(TrimArticle(strings.Fields(tc.in)), " "); trimmed != tc.want {
+			t.Errorf("TrimArticle(%q) = %q; want %q", tc.in, trimmed, tc.want)
+		}
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	testcases := []struct {
+		in   int
+		want string
+	}{
+		{1, "1st"},
+		{2, "2nd"},
+		{3, "3rd"},
+		{4, "4th"},
+		{11, "11th"},
+		{12, "12th"},
+		{21, "21st"},
+		{112, "112th"},
+	}
+
+	for _, tc := range testcases {
+		if ordinal := Ordinal(tc.in); ordinal != tc.want {
+			t.Errorf("Ordinal(%d) = %q; want %q", tc.in, ordinal, tc.want)
 		}
 	}
 }